@@ -0,0 +1,371 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/untillpro/gochips/errs"
+)
+
+// binding is a source of the target value given as a string, elem is the target
+type binding struct {
+	*srcElem
+	envVar   string
+	flagSet  *flag.FlagSet
+	flagName string
+}
+
+// ProvideFromEnv binds target to the environment variable, the value is converted to the target type by ResolveAll()
+// Supported types: strings, bools, numbers, time.Duration, slices ("a,b"), maps ("k1=v1,k2=v2") and encoding.TextUnmarshaler implementations
+// Missing value is an error if the target is required by Require(), otherwise the target is left as is
+func (c *Container) ProvideFromEnv(target interface{}, envVar string) {
+	c.provideFromEnv(target, envVar)
+}
+
+// ProvideFromFlag binds target to the flag of the flag set, flag set must be parsed before ResolveAll()
+// Flag which is not set on the command line gives its non-empty default if no other binding of the target has value
+// Conversion is the same as for ProvideFromEnv()
+func (c *Container) ProvideFromFlag(target interface{}, fs *flag.FlagSet, flagName string) {
+	c.provideFromFlag(target, fs, flagName)
+}
+
+// ProvideFromEnv binds target to the environment variable in the default container
+func ProvideFromEnv(target interface{}, envVar string) {
+	defaultContainer.provideFromEnv(target, envVar)
+}
+
+// ProvideFromFlag binds target to the flag of the flag set in the default container
+func ProvideFromFlag(target interface{}, fs *flag.FlagSet, flagName string) {
+	defaultContainer.provideFromFlag(target, fs, flagName)
+}
+
+func (c *Container) provideFromEnv(target interface{}, envVar string) {
+	pkg, file, line := caller(2)
+	c.addBinding(&binding{srcElem: newSrcElem(file, line, pkg, target), envVar: envVar})
+}
+
+func (c *Container) provideFromFlag(target interface{}, fs *flag.FlagSet, flagName string) {
+	pkg, file, line := caller(2)
+	c.addBinding(&binding{srcElem: newSrcElem(file, line, pkg, target), flagSet: fs, flagName: flagName})
+}
+
+func (c *Container) addBinding(b *binding) {
+	c.lock()
+	defer c.mu.Unlock()
+	if isHashable(b.elem) && reflect.TypeOf(b.elem).Kind() == reflect.Ptr {
+		c.bindings[b.elem] = append(c.bindings[b.elem], b)
+	} else {
+		c.unhashableProvs = append(c.unhashableProvs, b.src)
+	}
+}
+
+// source describes where the value is taken from
+func (b *binding) source() string {
+	if b.flagSet != nil {
+		return fmt.Sprintf("flag -%s", b.flagName)
+	}
+	return fmt.Sprintf("environment variable %s", b.envVar)
+}
+
+// lookup returns value of the environment variable or of the flag which is set on the command line
+func (b *binding) lookup() (value string, ok bool) {
+	if b.flagSet == nil {
+		return os.LookupEnv(b.envVar)
+	}
+	b.flagSet.Visit(func(f *flag.Flag) {
+		if f.Name == b.flagName {
+			value, ok = f.Value.String(), true
+		}
+	})
+	return value, ok
+}
+
+// defaultValue returns non-empty default of the flag
+func (b *binding) defaultValue() (value string, ok bool) {
+	if b.flagSet == nil {
+		return "", false
+	}
+	if f := b.flagSet.Lookup(b.flagName); f != nil && len(f.DefValue) > 0 {
+		return f.DefValue, true
+	}
+	return "", false
+}
+
+// isBound returns true if the container or one of its ancestors binds the target
+func (c *Container) isBound(target interface{}) bool {
+	for cur := c; cur != nil; cur = cur.parent {
+		if _, ok := cur.bindings[target]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isMandatory returns true if the nearest container which requires the target requires it as mandatory
+func (c *Container) isMandatory(target interface{}) bool {
+	for cur := c; cur != nil; cur = cur.parent {
+		if _, ok := cur.required[target]; ok {
+			return !cur.optional[target]
+		}
+	}
+	return false
+}
+
+// boundValue returns value of the last binding which has one, then the last non-empty flag default, nil if there is no value
+func boundValue(bindings []*binding) (*binding, string) {
+	for i := len(bindings) - 1; i >= 0; i-- {
+		if value, ok := bindings[i].lookup(); ok {
+			return bindings[i], value
+		}
+	}
+	for i := len(bindings) - 1; i >= 0; i-- {
+		if value, ok := bindings[i].defaultValue(); ok {
+			return bindings[i], value
+		}
+	}
+	return nil, ""
+}
+
+// hasNewBindings returns true if the target is bound since the last resolve
+func (c *Container) hasNewBindings(target interface{}) bool {
+	for _, b := range c.bindings[target] {
+		if !c.resolvedElems[b.srcElem] {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Container) validateBindings() (errs errs.Errors) {
+	for target, bindings := range c.bindings {
+		if !c.hasNewBindings(target) {
+			continue
+		}
+		targetType := reflect.TypeOf(target).Elem()
+		if !isBindable(targetType) {
+			errs.AddE(&EUnsupportedBindingType{bindings[0]})
+			continue
+		}
+		undefined := false
+		for _, b := range bindings {
+			if b.flagSet != nil && b.flagSet.Lookup(b.flagName) == nil {
+				errs.AddE(&EFlagNotDefined{b})
+				undefined = true
+			}
+		}
+		if undefined {
+			continue
+		}
+		b, value := boundValue(bindings)
+		if b == nil {
+			if c.isMandatory(target) {
+				errs.AddE(&EBoundValueNotProvided{bindings})
+			}
+			continue
+		}
+		if _, err := convertString(value, targetType); err != nil {
+			errs.AddE(&EBoundValueConversionFailed{b, value, err})
+		}
+	}
+	return errs
+}
+
+// validateRebindings reports new bindings of targets which are already injected or bound by the previous resolve
+func (c *Container) validateRebindings() (errs errs.Errors) {
+	for target, bindings := range c.bindings {
+		if !c.hasNewBindings(target) {
+			continue
+		}
+		injected := c.lookupInjected(target)
+		if injected == nil {
+			injected = c.lookupBound(target)
+		}
+		if injected == nil {
+			continue
+		}
+		for _, b := range bindings {
+			if !c.resolvedElems[b.srcElem] {
+				errs.AddE(&EAlreadyInjected{&srcPkgElem{srcElem: b.srcElem}, injected})
+			}
+		}
+	}
+	return errs
+}
+
+// lookupBound returns the last resolved binding of the target which is bound by the container or by the nearest ancestor
+func (c *Container) lookupBound(target interface{}) *srcPkgElem {
+	for cur := c; cur != nil; cur = cur.parent {
+		if !cur.bound[target] {
+			continue
+		}
+		bindings := cur.bindings[target]
+		for i := len(bindings) - 1; i >= 0; i-- {
+			if cur.resolvedElems[bindings[i].srcElem] {
+				return &srcPkgElem{srcElem: bindings[i].srcElem}
+			}
+		}
+	}
+	return nil
+}
+
+// injectBindings assigns values of new bindings, values win over implementations injected by provisions
+func (c *Container) injectBindings() {
+	for target, bindings := range c.bindings {
+		if !c.hasNewBindings(target) {
+			continue
+		}
+		b, value := boundValue(bindings)
+		if b == nil {
+			continue
+		}
+		// validated
+		v, _ := convertString(value, reflect.TypeOf(target).Elem())
+		if c.parent != nil {
+			c.setTarget(target, v)
+		} else {
+			reflect.ValueOf(target).Elem().Set(v)
+		}
+		c.bound[target] = true
+	}
+}
+
+// ParseValue converts s to the type of the target as ProvideFromEnv() does and assigns it, target must be a pointer
+func ParseValue(s string, target interface{}) error {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() {
+		return fmt.Errorf("target must be a non-nil pointer, got %T", target)
+	}
+	v, err := convertString(s, targetValue.Type().Elem())
+	if err != nil {
+		return err
+	}
+	targetValue.Elem().Set(v)
+	return nil
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+func isBindable(t reflect.Type) bool {
+	if t.Implements(textUnmarshalerType) && t.Kind() == reflect.Ptr || reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return isBindable(t.Elem())
+	case reflect.Map:
+		return isBindable(t.Key()) && isBindable(t.Elem())
+	}
+	return false
+}
+
+// convertString converts s to the value of t, t must be bindable
+// Slice elements are separated by comma, map entries are "key=value" separated by comma
+func convertString(s string, t reflect.Type) (reflect.Value, error) {
+	if t.Implements(textUnmarshalerType) && t.Kind() == reflect.Ptr {
+		v := reflect.New(t.Elem())
+		return v, v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		v := reflect.New(t)
+		return v.Elem(), v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return v, err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t == durationType {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return v, err
+			}
+			v.SetInt(int64(d))
+			break
+		}
+		i, err := strconv.ParseInt(s, 0, t.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 0, t.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, t.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(t, 0, 0))
+		for _, item := range splitList(s) {
+			elem, err := convertString(item, t.Elem())
+			if err != nil {
+				return v, err
+			}
+			v.Set(reflect.Append(v, elem))
+		}
+	case reflect.Map:
+		v.Set(reflect.MakeMap(t))
+		for _, item := range splitList(s) {
+			kv := strings.SplitN(item, "=", 2)
+			if len(kv) != 2 {
+				return v, fmt.Errorf("%q is not key=value", item)
+			}
+			key, err := convertString(strings.TrimSpace(kv[0]), t.Key())
+			if err != nil {
+				return v, err
+			}
+			value, err := convertString(strings.TrimSpace(kv[1]), t.Elem())
+			if err != nil {
+				return v, err
+			}
+			v.SetMapIndex(key, value)
+		}
+	default:
+		return v, fmt.Errorf("%s is not supported", t)
+	}
+	return v, nil
+}
+
+// splitList splits comma separated list, empty string is an empty list
+func splitList(s string) []string {
+	if len(strings.TrimSpace(s)) == 0 {
+		return nil
+	}
+	res := strings.Split(s, ",")
+	for i := range res {
+		res[i] = strings.TrimSpace(res[i])
+	}
+	return res
+}
@@ -0,0 +1,229 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"flag"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvideFromEnv(t *testing.T) {
+	Reset()
+	var port int
+	var debug bool
+	var timeout time.Duration
+	var hosts []string
+	var limits map[string]uint16
+	var ip net.IP
+	t.Setenv("GODIF_TEST_PORT", "8080")
+	t.Setenv("GODIF_TEST_DEBUG", "true")
+	t.Setenv("GODIF_TEST_TIMEOUT", "1m30s")
+	t.Setenv("GODIF_TEST_HOSTS", "a.example, b.example")
+	t.Setenv("GODIF_TEST_LIMITS", "read=10,write=2")
+	t.Setenv("GODIF_TEST_IP", "10.0.0.1")
+
+	Require(&port)
+	ProvideFromEnv(&port, "GODIF_TEST_PORT")
+	ProvideFromEnv(&debug, "GODIF_TEST_DEBUG")
+	ProvideFromEnv(&timeout, "GODIF_TEST_TIMEOUT")
+	ProvideFromEnv(&hosts, "GODIF_TEST_HOSTS")
+	ProvideFromEnv(&limits, "GODIF_TEST_LIMITS")
+	ProvideFromEnv(&ip, "GODIF_TEST_IP")
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 8080, port)
+	require.True(t, debug)
+	require.Equal(t, 90*time.Second, timeout)
+	require.Equal(t, []string{"a.example", "b.example"}, hosts)
+	require.Equal(t, map[string]uint16{"read": 10, "write": 2}, limits)
+	require.Equal(t, "10.0.0.1", ip.String())
+
+	Reset()
+	require.Equal(t, 0, port)
+	require.Nil(t, hosts)
+}
+
+func TestProvideFromFlag(t *testing.T) {
+	var port int
+	var name string
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("port", "", "")
+	fs.String("name", "", "")
+	require.Nil(t, fs.Parse([]string{"-port", "9090"}))
+	t.Setenv("GODIF_TEST_PORT", "8080")
+	t.Setenv("GODIF_TEST_NAME", "env")
+
+	c := NewContainer()
+	c.Require(&port)
+	c.ProvideFromEnv(&port, "GODIF_TEST_PORT")
+	c.ProvideFromFlag(&port, fs, "port")
+	c.ProvideFromEnv(&name, "GODIF_TEST_NAME")
+	c.ProvideFromFlag(&name, fs, "name")
+	errs := c.ResolveAll()
+	require.Nil(t, errs)
+	defer c.Reset()
+
+	// the last binding which has value wins
+	require.Equal(t, 9090, port)
+	// flag is not set on the command line
+	require.Equal(t, "env", name)
+}
+
+func TestBoundValueNotProvided(t *testing.T) {
+	var port int
+	var optionalPort = 80
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("port", "", "")
+	c := NewContainer()
+	c.Require(&port)
+	c.RequireOptional(&optionalPort)
+	c.ProvideFromEnv(&optionalPort, "GODIF_TEST_NOT_SET")
+	_, _, line, _ := runtime.Caller(0)
+	c.ProvideFromEnv(&port, "GODIF_TEST_NOT_SET")
+	c.ProvideFromFlag(&port, fs, "port")
+
+	errs := c.ResolveAll()
+	if e, ok := errs[0].(*EBoundValueNotProvided); ok && len(errs) == 1 {
+		require.Equal(t, line+1, e.bindings[0].line)
+		require.Equal(t, 2, len(e.bindings))
+		require.Contains(t, e.Error(), "environment variable GODIF_TEST_NOT_SET")
+		require.Contains(t, e.Error(), "flag -port")
+	} else {
+		t.Fatal(errs)
+	}
+	require.Equal(t, 80, optionalPort)
+
+	// target which got no value is kept as manually inited var
+	c.Reset()
+	require.Equal(t, 80, optionalPort)
+}
+
+func TestProvideFromFlagDefault(t *testing.T) {
+	var port int
+	var timeout = time.Second
+	var limit = 10
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("port", 8080, "")
+	fs.String("timeout", "", "")
+	require.Nil(t, fs.Parse(nil))
+	t.Setenv("GODIF_TEST_LIMIT", "20")
+
+	c := NewContainer()
+	c.Require(&port)
+	c.ProvideFromFlag(&port, fs, "port")
+	c.ProvideFromFlag(&timeout, fs, "timeout")
+	c.ProvideFromEnv(&limit, "GODIF_TEST_LIMIT")
+	require.Nil(t, c.ResolveAll())
+	require.Equal(t, 8080, port)
+	require.Equal(t, time.Second, timeout)
+	require.Equal(t, 20, limit)
+
+	c.Reset()
+	require.Equal(t, 0, port)
+	require.Equal(t, time.Second, timeout)
+	require.Equal(t, 0, limit)
+}
+
+func TestFlagNotDefined(t *testing.T) {
+	var port int
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	c := NewContainer()
+	_, _, line, _ := runtime.Caller(0)
+	c.ProvideFromFlag(&port, fs, "port")
+
+	errs := c.ResolveAll()
+	if e, ok := errs[0].(*EFlagNotDefined); ok && len(errs) == 1 {
+		require.Equal(t, line+1, e.binding.line)
+		require.Contains(t, e.Error(), "flag -port")
+	} else {
+		t.Fatal(errs)
+	}
+}
+
+func TestBoundValueConversionFailed(t *testing.T) {
+	var port uint8
+	var timeout time.Duration
+	t.Setenv("GODIF_TEST_PORT", "8080")
+	t.Setenv("GODIF_TEST_TIMEOUT", "1 minute")
+	c := NewContainer()
+	_, file, line, _ := runtime.Caller(0)
+	c.ProvideFromEnv(&port, "GODIF_TEST_PORT")
+	c.ProvideFromEnv(&timeout, "GODIF_TEST_TIMEOUT")
+
+	errs := c.ResolveAll()
+	require.Equal(t, 2, len(errs))
+	if e, ok := errs[0].(*EBoundValueConversionFailed); ok {
+		require.Equal(t, file, e.binding.file)
+		require.Equal(t, line+1, e.binding.line)
+		require.Equal(t, "8080", e.value)
+	} else {
+		t.Fatal(errs)
+	}
+	if e, ok := errs[1].(*EBoundValueConversionFailed); ok {
+		require.Equal(t, line+2, e.binding.line)
+	} else {
+		t.Fatal(errs)
+	}
+	require.Equal(t, uint8(0), port)
+}
+
+func TestUnsupportedBindingType(t *testing.T) {
+	var injectedFunc func(x int, y int) int
+	c := NewContainer()
+	c.ProvideFromEnv(&injectedFunc, "GODIF_TEST_FUNC")
+	_, _, line, _ := runtime.Caller(0)
+
+	errs := c.ResolveAll()
+	if e, ok := errs[0].(*EUnsupportedBindingType); ok && len(errs) == 1 {
+		require.Equal(t, line-1, e.binding.line)
+	} else {
+		t.Fatal(errs)
+	}
+}
+
+func TestBindingErrorOnAlreadyInjected(t *testing.T) {
+	Reset()
+	var ports []string
+	var port int
+	t.Setenv("GODIF_TEST_PORTS", "b,c")
+	t.Setenv("GODIF_TEST_PORT", "8080")
+	t.Setenv("GODIF_TEST_PORT2", "9090")
+
+	Require(&ports)
+	_, injectedFile, injectedLine, _ := runtime.Caller(0)
+	Provide(&ports, []string{"a"})
+	ProvideFromEnv(&port, "GODIF_TEST_PORT")
+	require.Nil(t, ResolveAll())
+
+	_, file, line, _ := runtime.Caller(0)
+	ProvideFromEnv(&ports, "GODIF_TEST_PORTS")
+	ProvideFromEnv(&port, "GODIF_TEST_PORT2")
+
+	errs := ResolveIncremental()
+	require.Len(t, errs, 2, errs)
+	for i, err := range errs {
+		if e, ok := err.(*EAlreadyInjected); ok {
+			require.Equal(t, file, e.prov.file)
+			require.Equal(t, line+i+1, e.prov.line)
+			require.Equal(t, injectedFile, e.injected.file)
+			require.Equal(t, injectedLine+i+1, e.injected.line)
+		} else {
+			t.Fatal(errs)
+		}
+	}
+
+	// nothing is injected
+	require.Equal(t, []string{"a"}, ports)
+	require.Equal(t, 8080, port)
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"reflect"
+)
+
+// NewChild creates a container derived from c
+// Child provisions override provisions of c, requirements which are not provided in the child are provided by c
+// Data provided by ProvideKeyValue() and ProvideSliceElement() is added to copies of the storages, so c is not affected
+// c must be resolved before the child, child's Reset() restores values which were assigned before child's ResolveAll()
+// Children write to the same vars, so only one child of c can be resolved at a time, another one gets ESiblingResolved
+func (c *Container) NewChild() *Container {
+	child := NewContainer()
+	child.parent = c
+	return child
+}
+
+// NewChild creates a child of the default container
+func NewChild() *Container {
+	return defaultContainer.NewChild()
+}
+
+// lookupProvided returns provisions made in the container or, if there are none, in the nearest ancestor
+func (c *Container) lookupProvided(target interface{}) []*srcPkgElem {
+	for cur := c; cur != nil; cur = cur.parent {
+		if provs, ok := cur.provided[target]; ok {
+			return provs
+		}
+	}
+	return nil
+}
+
+// requiredView returns own requirements and requirements of ancestors which are overridden by the container
+func (c *Container) requiredView() map[interface{}]*srcElem {
+	if c.parent == nil {
+		return c.required
+	}
+	res := make(map[interface{}]*srcElem)
+	for cur := c.parent; cur != nil; cur = cur.parent {
+		for target, req := range cur.required {
+			if !c.overrides(target) {
+				continue
+			}
+			if _, ok := res[target]; !ok {
+				res[target] = req
+			}
+		}
+	}
+	for target, req := range c.required {
+		res[target] = req
+	}
+	return res
+}
+
+// overrides returns true if the container provides or selects implementation for the target
+func (c *Container) overrides(target interface{}) bool {
+	if _, ok := c.provided[target]; ok {
+		return true
+	}
+	_, ok := c.selections[target]
+	return ok
+}
+
+func (c *Container) injectChild() {
+	required := c.requiredView()
+
+	for target, provVar := range c.provided {
+		if _, ok := required[target]; !ok {
+			if _, ok := c.keyValues[target]; !ok {
+				if _, ok := c.sliceElements[target]; !ok {
+					continue
+				}
+			}
+		}
+		// funcs, interfaces and pointers are overridden, storages are initialized if nil only
+		targetValue := reflect.ValueOf(target).Elem()
+		if isService(targetValue.Kind()) || isEmpty(targetValue) {
+			prov := c.selectProvs(target, provVar)[0]
+			c.setTarget(target, c.decoratedValue(target, prov))
+			c.injected[target] = prov
+		}
+	}
+
+	for target := range required {
+		if _, ok := c.provided[target]; ok {
+			continue
+		}
+		_, selected := c.selections[target]
+		provs := c.effectiveProvided(target)
+		if provs == nil {
+			// optional requirement
+			continue
+		}
+		if targetValue := reflect.ValueOf(target).Elem(); selected || isEmpty(targetValue) {
+			prov := provs[0]
+			c.setTarget(target, c.decoratedValue(target, prov))
+			c.injected[target] = prov
+		}
+	}
+
+	// own decorators wrap implementations which are inherited as is
+	for target, decs := range c.decorators {
+		targetValue := reflect.ValueOf(target).Elem()
+		if _, ok := c.injected[target]; ok || isEmpty(targetValue) {
+			continue
+		}
+		c.setTarget(target, decorate(targetValue.Type(), targetValue, sortDecorators(append([]*decorator{}, decs...))))
+	}
+
+	for targetMap, kvToAppend := range c.keyValues {
+		baseMap := reflect.ValueOf(targetMap).Elem()
+		if baseMap.IsNil() {
+			prov := c.effectiveProvided(targetMap)[0]
+			baseMap = reflect.ValueOf(prov.elem)
+			c.injected[targetMap] = prov
+		}
+		newMap := reflect.New(baseMap.Type()).Elem()
+		newMap.Set(reflect.MakeMapWithSize(baseMap.Type(), baseMap.Len()))
+		iter := baseMap.MapRange()
+		for iter.Next() {
+			newMap.SetMapIndex(iter.Key(), iter.Value())
+		}
+		appendKeyValues(newMap, kvToAppend, c.keyOrder[targetMap])
+		c.setTarget(targetMap, newMap)
+	}
+
+	for targetSlice, elementsToAppend := range c.sliceElements {
+		baseSlice := reflect.ValueOf(targetSlice).Elem()
+		newSlice := reflect.New(baseSlice.Type()).Elem()
+		newSlice.Set(reflect.AppendSlice(newSlice, baseSlice))
+		appendSliceElements(newSlice, c.orderedSliceElements(elementsToAppend))
+		c.setTarget(targetSlice, newSlice)
+	}
+}
+
+// setTarget remembers the value of the target before the first assignment, so it can be restored on Reset()
+func (c *Container) setTarget(target interface{}, value reflect.Value) {
+	targetValue := reflect.ValueOf(target).Elem()
+	if _, ok := c.saved[target]; !ok {
+		prev := reflect.New(targetValue.Type()).Elem()
+		prev.Set(targetValue)
+		c.saved[target] = prev
+	}
+	targetValue.Set(value)
+}
+
+func (c *Container) restoreTargets() {
+	for target, prev := range c.saved {
+		reflect.ValueOf(target).Elem().Set(prev)
+	}
+}
@@ -0,0 +1,222 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChildOverridesFunc(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	parent := NewContainer()
+	var injectedFunc1 func(x int, y int) int
+	var injectedFunc2 func(x int, y int) int
+
+	parent.Require(&injectedFunc1)
+	parent.Require(&injectedFunc2)
+	parent.Provide(&injectedFunc1, f)
+	parent.Provide(&injectedFunc2, f)
+	require.Nil(parent.ResolveAll())
+
+	child := parent.NewChild()
+	child.Provide(&injectedFunc1, f3)
+	require.Nil(child.ResolveAll())
+	require.Equal(6, injectedFunc1(3, 2))
+	require.Equal(5, injectedFunc2(3, 2))
+
+	// parent's values are restored
+	child.Reset()
+	require.Equal(5, injectedFunc1(3, 2))
+	require.Equal(5, injectedFunc2(3, 2))
+
+	parent.Reset()
+	require.Nil(injectedFunc1)
+	require.Nil(injectedFunc2)
+}
+
+func TestChildSiblings(t *testing.T) {
+	t.Parallel()
+	parent := NewContainer()
+	var injectedFunc func(x int, y int) int
+
+	parent.Require(&injectedFunc)
+	parent.Provide(&injectedFunc, f)
+	require.Nil(t, parent.ResolveAll())
+
+	c1 := parent.NewChild()
+	c1.Provide(&injectedFunc, f3)
+	_, _, line, _ := runtime.Caller(0)
+	require.Nil(t, c1.ResolveAll())
+
+	c2 := parent.NewChild()
+	c2.Provide(&injectedFunc, func(x int, y int) int { return 100 })
+	errs := c2.ResolveAll()
+	if e, ok := errs[0].(*ESiblingResolved); ok && len(errs) == 1 {
+		require.Equal(t, line+1, e.resolvePlace.line)
+	} else {
+		t.Fatal(errs)
+	}
+	require.Equal(t, 6, injectedFunc(3, 2))
+
+	c1.Reset()
+	require.Equal(t, 5, injectedFunc(3, 2))
+	require.Nil(t, c2.ResolveAll())
+	require.Equal(t, 100, injectedFunc(3, 2))
+	c2.Reset()
+	require.Equal(t, 5, injectedFunc(3, 2))
+}
+
+func TestChildRequirementsFallThroughToParent(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	parent := NewContainer()
+	var injectedFunc1 func(x int, y int) int
+	var injectedFunc2 func(x int, y int) int
+
+	parent.Require(&injectedFunc1)
+	parent.Provide(&injectedFunc1, f)
+	parent.Provide(&injectedFunc2, f3)
+	require.Nil(parent.ResolveAll())
+	require.Nil(injectedFunc2)
+
+	child := parent.NewChild()
+	child.Require(&injectedFunc2)
+	require.Nil(child.ResolveAll())
+	require.Equal(6, injectedFunc2(3, 2))
+
+	child.Reset()
+	require.Nil(injectedFunc2)
+	require.NotNil(injectedFunc1)
+}
+
+func TestChildExtendsStorages(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	parent := NewContainer()
+	var myMap map[string][]int
+	var mySlice []string
+
+	parent.Provide(&myMap, map[string][]int{})
+	parent.ProvideKeyValue(&myMap, "key1", 1)
+	parent.ProvideSliceElement(&mySlice, "str1")
+	require.Nil(parent.ResolveAll())
+
+	child := parent.NewChild()
+	child.ProvideKeyValue(&myMap, "key1", 2)
+	child.ProvideKeyValue(&myMap, "key2", 3)
+	child.ProvideSliceElement(&mySlice, "str2")
+	parentMap := myMap
+	require.Nil(child.ResolveAll())
+	require.Equal(map[string][]int{"key1": {1, 2}, "key2": {3}}, myMap)
+	require.Equal([]string{"str1", "str2"}, mySlice)
+
+	// parent's storages are not affected
+	require.Equal(map[string][]int{"key1": {1}}, parentMap)
+
+	child.Reset()
+	require.Equal(map[string][]int{"key1": {1}}, myMap)
+	require.Equal([]string{"str1"}, mySlice)
+}
+
+func TestChildErrors(t *testing.T) {
+	t.Parallel()
+	parent := NewContainer()
+	var injectedFunc1 func(x int, y int) int
+	var injectedFunc2 func(x int, y int) int
+	var myMap map[string]int
+
+	parent.Require(&injectedFunc1)
+	parent.Provide(&injectedFunc1, f)
+
+	child := parent.NewChild()
+	errs := child.ResolveAll()
+	if _, ok := errs[0].(*EParentNotResolved); ok && len(errs) == 1 {
+		fmt.Println(errs)
+	} else {
+		t.Fatal(errs)
+	}
+
+	require.Nil(t, parent.ResolveAll())
+
+	// Requirements of both levels are checked
+	child = parent.NewChild()
+	child.Require(&injectedFunc2)
+	child.Provide(&injectedFunc1, f2)
+	child.ProvideKeyValue(&myMap, "key1", 1)
+	errs = child.ResolveAll()
+	require.Len(t, errs, 3, errs)
+	fmt.Println(errs)
+	var notProvided, incompatible int
+	for _, err := range errs {
+		switch err.(type) {
+		case *EImplementationNotProvided:
+			notProvided++
+		case *EIncompatibleTypesFunc:
+			incompatible++
+		}
+	}
+	require.Equal(t, 2, notProvided)
+	require.Equal(t, 1, incompatible)
+	require.Equal(t, 5, injectedFunc1(3, 2))
+}
+
+func TestChildOfChild(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	parent := NewContainer()
+	var injectedFunc func(x int, y int) int
+	var mySlice []int
+
+	parent.Require(&injectedFunc)
+	parent.Provide(&injectedFunc, f)
+	parent.ProvideSliceElement(&mySlice, 1)
+	require.Nil(parent.ResolveAll())
+
+	child := parent.NewChild()
+	child.Provide(&injectedFunc, f3)
+	child.ProvideSliceElement(&mySlice, 2)
+	require.Nil(child.ResolveAll())
+
+	grandChild := child.NewChild()
+	grandChild.ProvideSliceElement(&mySlice, 3)
+	require.Nil(grandChild.ResolveAll())
+	require.Equal(6, injectedFunc(3, 2))
+	require.Equal([]int{1, 2, 3}, mySlice)
+
+	grandChild.Reset()
+	require.Equal([]int{1, 2}, mySlice)
+	child.Reset()
+	require.Equal([]int{1}, mySlice)
+	require.Equal(5, injectedFunc(3, 2))
+}
+
+type childStringer string
+
+func (s childStringer) String() string { return string(s) }
+
+func TestChildOverridesInterface(t *testing.T) {
+	require := require.New(t)
+	var stringer fmt.Stringer
+	parent := NewContainer()
+	parent.Require(&stringer)
+	parent.Provide(&stringer, childStringer("parent"))
+	require.Nil(parent.ResolveAll())
+
+	child := parent.NewChild()
+	child.Provide(&stringer, childStringer("child"))
+	require.Nil(child.ResolveAll())
+	require.Equal("child", stringer.String())
+
+	child.Reset()
+	require.Equal("parent", stringer.String())
+}
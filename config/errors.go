@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package config
+
+import (
+	"fmt"
+)
+
+// EInvalidConfig occurs if config can't be parsed, Line is 0 for JSON config
+type EInvalidConfig struct {
+	Line int
+	Err  error
+}
+
+// EInvalidKey occurs if Target of the declared key is not a pointer or Default is not assignable to it
+type EInvalidKey struct {
+	Name    string
+	Package string
+	Err     error
+}
+
+// EKeyNotDeclared occurs if config has a key which is not declared by any package
+type EKeyNotDeclared struct {
+	Name string
+}
+
+// EValueNotProvided occurs if config has no value for the key which has no default
+type EValueNotProvided struct {
+	Name    string
+	Package string
+}
+
+// EInvalidValue occurs if value can't be converted to the type of the key or is rejected by Validate
+type EInvalidValue struct {
+	Name    string
+	Package string
+	Value   string
+	Err     error
+}
+
+func (e *EInvalidConfig) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("Invalid config at line %d: %s", e.Line, e.Err)
+	}
+	return fmt.Sprintf("Invalid config: %s", e.Err)
+}
+
+func (e *EInvalidKey) Error() string {
+	return fmt.Sprintf("Key %s declared by %s is invalid: %s", e.Name, e.Package, e.Err)
+}
+
+func (e *EKeyNotDeclared) Error() string {
+	return fmt.Sprintf("Key %s is not declared", e.Name)
+}
+
+func (e *EValueNotProvided) Error() string {
+	return fmt.Sprintf("Value of key %s declared by %s is not provided", e.Name, e.Package)
+}
+
+func (e *EInvalidValue) Error() string {
+	return fmt.Sprintf("Value %s of key %s declared by %s is invalid: %s", e.Value, e.Name, e.Package, e.Err)
+}
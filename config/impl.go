@@ -0,0 +1,507 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/untillpro/gochips/errs"
+	"github.com/untillpro/godif"
+)
+
+// Keys should be declared by godif.ProvideKeyValue(&config.Keys, "http.port", &config.Key{Target: &port, Default: 8080, Description: "..."})
+// Declaring the same key twice is reported by godif.ResolveAll() as EMultipleValues
+var Keys map[string]*Key
+
+// pending are configs which are applied by loaders during resolve called by ResolveAllData(), by container, nil is the default container
+var pending = make(map[*godif.Container]*pendingConfig)
+
+// pendingMu guards pending, resolveMu serializes ResolveAllData() calls so pending config is not replaced during resolve
+var pendingMu, resolveMu sync.Mutex
+
+type pendingConfig struct {
+	data []byte
+	// pkgs are packages which declare keys by name, they are taken before resolve since registry is locked during resolve
+	pkgs map[string]string
+}
+
+// Declare s.e.
+func Declare() {
+	godif.Provide(&Keys, map[string]*Key{})
+	godif.ProvideLoader(&Keys, loader(nil))
+}
+
+// DeclareIn works as Declare() for the container
+func DeclareIn(c *godif.Container) {
+	c.Provide(&Keys, map[string]*Key{})
+	c.ProvideLoader(&Keys, loader(c))
+}
+
+// ResolveAll loads the config file and resolves it by ResolveAllData()
+func ResolveAll(path string) errs.Errors {
+	return resolveAll(nil, path)
+}
+
+// ResolveAllIn works as ResolveAll() for the container, keys must be declared by DeclareIn()
+func ResolveAllIn(c *godif.Container, path string) errs.Errors {
+	return resolveAll(c, path)
+}
+
+// ResolveAllData calls godif.ResolveAll() which validates config against declared keys and assigns values to targets of the keys
+// before constructors are called, so constructors and decorators see the values
+// Nothing is assigned if there are config errors, plain godif.ResolveAll() does not assign values
+// Config is JSON if it starts with '{', otherwise it is YAML-like:
+// `key: value` lines, `section:` lines with indented keys, `- item` lines and `[a, b]` lists, `#` comments
+// Nested JSON objects and sections give dotted key names, e.g. `http.port`
+// String values and list items are converted as godif.ProvideFromEnv() does, other JSON values are unmarshaled into the target type
+// Keys which are missing in config get defaults
+func ResolveAllData(data []byte) errs.Errors {
+	return resolveAllData(nil, data, 1)
+}
+
+// ResolveAllDataIn works as ResolveAllData() for the container, keys must be declared by DeclareIn()
+func ResolveAllDataIn(c *godif.Container, data []byte) errs.Errors {
+	return resolveAllData(c, data, 1)
+}
+
+func resolveAll(c *godif.Container, path string) errs.Errors {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errs.Errors{err}
+	}
+	return resolveAllData(c, data, 2)
+}
+
+// resolveAllData resolves the container, nil is the default one, skip is the number of config functions above resolveAllData(),
+// so the caller of the config function is reported as the resolve location
+func resolveAllData(c *godif.Container, data []byte, skip int) errs.Errors {
+	resolveMu.Lock()
+	defer resolveMu.Unlock()
+
+	pendingMu.Lock()
+	pending[c] = &pendingConfig{data, declaringPackages(c)}
+	pendingMu.Unlock()
+	defer func() {
+		pendingMu.Lock()
+		delete(pending, c)
+		pendingMu.Unlock()
+	}()
+
+	opts := godif.Options{CallerSkip: skip + 1}
+	var res errs.Errors
+	if c == nil {
+		res, _ = godif.ResolveAllWithOptions(opts)
+	} else {
+		res, _ = c.ResolveAllWithOptions(opts)
+	}
+	return res
+}
+
+// loader returns the loader of Keys in the container, nil is the default container
+func loader(c *godif.Container) func(keys map[string]*Key) error {
+	return func(keys map[string]*Key) error {
+		pendingMu.Lock()
+		cfg := pending[c]
+		pendingMu.Unlock()
+		if cfg == nil {
+			return nil
+		}
+		if res := apply(keys, cfg.data, cfg.pkgs); res != nil {
+			return res
+		}
+		return nil
+	}
+}
+
+func apply(keys map[string]*Key, data []byte, pkgs map[string]string) errs.Errors {
+	values, err := parse(data, keys)
+	if err != nil {
+		return errs.Errors{err}
+	}
+	var res errs.Errors
+	for _, name := range sortedNames(values) {
+		if _, ok := keys[name]; !ok {
+			res.AddE(&EKeyNotDeclared{name})
+		}
+	}
+
+	type assignment struct {
+		target reflect.Value
+		value  reflect.Value
+	}
+	var assignments []assignment
+	for _, name := range sortedNames(keys) {
+		key := keys[name]
+		targetValue := reflect.ValueOf(key.Target)
+		if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() {
+			res.AddE(&EInvalidKey{name, pkgs[name], fmt.Errorf("Target must be a non-nil pointer, got %T", key.Target)})
+			continue
+		}
+		targetType := targetValue.Type().Elem()
+		var v reflect.Value
+		if raw, ok := values[name]; ok {
+			if v, err = raw.decode(targetType); err != nil {
+				res.AddE(&EInvalidValue{name, pkgs[name], raw.String(), err})
+				continue
+			}
+		} else if key.Default != nil {
+			v = reflect.ValueOf(key.Default)
+			if !v.Type().AssignableTo(targetType) {
+				res.AddE(&EInvalidKey{name, pkgs[name], fmt.Errorf("Default %T is not assignable to %s", key.Default, targetType)})
+				continue
+			}
+		} else {
+			res.AddE(&EValueNotProvided{name, pkgs[name]})
+			continue
+		}
+		if key.Validate != nil {
+			if err := key.Validate(v.Interface()); err != nil {
+				res.AddE(&EInvalidValue{name, pkgs[name], fmt.Sprint(v.Interface()), err})
+				continue
+			}
+		}
+		assignments = append(assignments, assignment{targetValue.Elem(), v})
+	}
+	if res != nil {
+		return res
+	}
+
+	for _, a := range assignments {
+		a.target.Set(a.value)
+	}
+	return nil
+}
+
+// Docs returns Markdown table of declared keys sorted by name, Keys must be injected by godif.ResolveAll()
+func Docs() string {
+	pkgs := declaringPackages(nil)
+	var sb strings.Builder
+	sb.WriteString("| Key | Type | Default | Description | Package |\n")
+	sb.WriteString("|-----|------|---------|-------------|---------|\n")
+	for _, name := range sortedNames(Keys) {
+		key := Keys[name]
+		def := "*required*"
+		if key.Default != nil {
+			def = fmt.Sprintf("`%v`", key.Default)
+		}
+		typeName := "?"
+		if t := reflect.TypeOf(key.Target); t != nil && t.Kind() == reflect.Ptr {
+			typeName = t.Elem().String()
+		}
+		fmt.Fprintf(&sb, "| `%s` | `%s` | %s | %s | `%s` |\n", name, typeName, def, strings.ReplaceAll(key.Description, "|", "\\|"), pkgs[name])
+	}
+	return sb.String()
+}
+
+// declaringPackages returns packages which declare keys by name in the container, nil is the default container
+func declaringPackages(c *godif.Container) map[string]string {
+	var reg godif.RegistrySnapshot
+	if c == nil {
+		reg = godif.Registry()
+	} else {
+		reg = c.Registry()
+	}
+	res := make(map[string]string)
+	for _, kv := range reg.KeyValues {
+		if kv.Target != &Keys {
+			continue
+		}
+		if name, ok := kv.Key.(string); ok {
+			res[name] = kv.Package
+		}
+	}
+	return res
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	res := make([]string, 0, len(m))
+	for name := range m {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// value is either a text or a list of YAML-like config or a JSON value
+type value struct {
+	text string
+	// list is not nil for `- item` lines and `[a, b]` lists
+	list []string
+	json json.RawMessage
+}
+
+func (v *value) String() string {
+	switch {
+	case v.json != nil:
+		return string(v.json)
+	case v.list != nil:
+		return "[" + strings.Join(v.list, ", ") + "]"
+	}
+	return v.text
+}
+
+func (v *value) decode(t reflect.Type) (reflect.Value, error) {
+	ptr := reflect.New(t)
+	if v.list != nil {
+		if t.Kind() != reflect.Slice {
+			return ptr.Elem(), fmt.Errorf("list can't be converted to %s", t)
+		}
+		res := reflect.MakeSlice(t, 0, len(v.list))
+		for _, item := range v.list {
+			elem := reflect.New(t.Elem())
+			if err := godif.ParseValue(item, elem.Interface()); err != nil {
+				return ptr.Elem(), err
+			}
+			res = reflect.Append(res, elem.Elem())
+		}
+		return res, nil
+	}
+	if v.json == nil {
+		return ptr.Elem(), godif.ParseValue(v.text, ptr.Interface())
+	}
+	var s string
+	if err := json.Unmarshal(v.json, &s); err == nil {
+		return ptr.Elem(), godif.ParseValue(s, ptr.Interface())
+	}
+	return ptr.Elem(), json.Unmarshal(v.json, ptr.Interface())
+}
+
+func parse(data []byte, keys map[string]*Key) (map[string]*value, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return parseJSON(data, keys)
+	}
+	return parseText(data)
+}
+
+func parseJSON(data []byte, keys map[string]*Key) (map[string]*value, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, &EInvalidConfig{0, err}
+	}
+	if err := checkRepeatedKeys(data); err != nil {
+		return nil, &EInvalidConfig{0, err}
+	}
+	res := make(map[string]*value)
+	if err := flattenJSON("", obj, keys, res); err != nil {
+		return nil, &EInvalidConfig{0, err}
+	}
+	return res, nil
+}
+
+// checkRepeatedKeys reports key which is repeated in the same object at any level, e.g. {"port": 1, "port": 2}
+// json.Unmarshal silently takes the last value of such key
+func checkRepeatedKeys(data []byte) error {
+	// frame is an object or an array, keys is nil for arrays
+	type frame struct {
+		name      string
+		keys      map[string]bool
+		expectKey bool
+	}
+	var stack []*frame
+	name := ""
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var top *frame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		if top != nil && top.keys == nil {
+			// items of the array have the name of the array
+			name = top.name
+		}
+		switch tok {
+		case json.Delim('{'):
+			stack = append(stack, &frame{name: name, keys: make(map[string]bool), expectKey: true})
+			continue
+		case json.Delim('['):
+			stack = append(stack, &frame{name: name})
+			continue
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 {
+				top = stack[len(stack)-1]
+			} else {
+				top = nil
+			}
+		default:
+			if top != nil && top.expectKey {
+				key := tok.(string)
+				if top.keys[key] {
+					return fmt.Errorf("key %s is duplicated", top.name+key)
+				}
+				top.keys[key] = true
+				top.expectKey = false
+				name = top.name + key + "."
+				continue
+			}
+		}
+		// value is complete
+		if top != nil && top.keys != nil {
+			top.expectKey = true
+		}
+	}
+}
+
+// flattenJSON gives dotted names to values of nested objects, objects are kept as is if they are values of declared keys
+// Same name given by dotted and nested keys, e.g. {"http.port": 1, "http": {"port": 2}}, is an error
+func flattenJSON(prefix string, obj map[string]json.RawMessage, keys map[string]*Key, res map[string]*value) error {
+	for k, raw := range obj {
+		name := prefix + k
+		if _, declared := keys[name]; !declared && bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+			var nested map[string]json.RawMessage
+			if json.Unmarshal(raw, &nested) == nil {
+				if err := flattenJSON(name+".", nested, keys, res); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if _, ok := res[name]; ok {
+			return fmt.Errorf("key %s is duplicated", name)
+		}
+		res[name] = &value{json: raw}
+	}
+	return nil
+}
+
+func parseText(data []byte) (map[string]*value, error) {
+	type section struct {
+		indent int
+		name   string
+		keys   int
+		items  int
+	}
+	var sections []*section
+	res := make(map[string]*value)
+	for i, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(stripComment(line))
+		if len(trimmed) == 0 {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		item := trimmed == "-" || strings.HasPrefix(trimmed, "- ")
+		// items of the list may have the same indent as its key
+		for len(sections) > 0 {
+			top := sections[len(sections)-1]
+			if top.indent < indent || item && top.indent == indent && top.keys == 0 {
+				break
+			}
+			sections = sections[:len(sections)-1]
+		}
+		var parent *section
+		if len(sections) > 0 {
+			parent = sections[len(sections)-1]
+		}
+
+		if item {
+			if parent == nil || parent.keys > 0 {
+				return nil, &EInvalidConfig{i + 1, fmt.Errorf("%q is not an item of a list", trimmed)}
+			}
+			if parent.items == 0 {
+				if _, ok := res[parent.name]; ok {
+					return nil, &EInvalidConfig{i + 1, fmt.Errorf("key %s is duplicated", parent.name)}
+				}
+				res[parent.name] = &value{list: []string{}}
+			}
+			parent.items++
+			res[parent.name].list = append(res[parent.name].list, unquote(strings.TrimSpace(trimmed[1:])))
+			continue
+		}
+		if parent != nil && parent.items > 0 {
+			return nil, &EInvalidConfig{i + 1, fmt.Errorf("%q is not an item of list %s", trimmed, parent.name)}
+		}
+
+		colon := strings.Index(trimmed, ":")
+		if colon <= 0 {
+			return nil, &EInvalidConfig{i + 1, fmt.Errorf("%q is not `key: value`", trimmed)}
+		}
+		name := strings.TrimSpace(trimmed[:colon])
+		if parent != nil {
+			name = parent.name + "." + name
+			parent.keys++
+		}
+		text := strings.TrimSpace(trimmed[colon+1:])
+		if len(text) == 0 {
+			sections = append(sections, &section{indent: indent, name: name})
+			continue
+		}
+		if _, ok := res[name]; ok {
+			return nil, &EInvalidConfig{i + 1, fmt.Errorf("key %s is duplicated", name)}
+		}
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			res[name] = &value{list: splitFlow(text[1 : len(text)-1])}
+		} else {
+			res[name] = &value{text: unquote(text)}
+		}
+	}
+	return res, nil
+}
+
+// stripComment cuts `#` comment which starts the line or follows whitespace outside of quotes
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// splitFlow splits items of `[a, b]` list by commas outside of quotes, empty text is an empty list
+func splitFlow(s string) []string {
+	res := []string{}
+	if len(strings.TrimSpace(s)) == 0 {
+		return res
+	}
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			res = append(res, unquote(strings.TrimSpace(s[start:i])))
+			start = i + 1
+		}
+	}
+	return append(res, unquote(strings.TrimSpace(s[start:])))
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,259 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/untillpro/godif"
+)
+
+const testPkg = "github.com/untillpro/godif/config"
+
+type httpConfig struct {
+	port    int
+	timeout time.Duration
+	hosts   []string
+	debug   bool
+}
+
+func declareHTTP(cfg *httpConfig) {
+	Declare()
+	godif.ProvideKeyValue(&Keys, "http.port", &Key{Target: &cfg.port, Default: 8080, Description: "HTTP port",
+		Validate: func(value interface{}) error {
+			if port := value.(int); port <= 0 || port > 65535 {
+				return errors.New("port must be in 1..65535")
+			}
+			return nil
+		}})
+	godif.ProvideKeyValue(&Keys, "http.timeout", &Key{Target: &cfg.timeout, Description: "Request timeout"})
+	godif.ProvideKeyValue(&Keys, "http.hosts", &Key{Target: &cfg.hosts, Default: []string{"localhost"}, Description: "Allowed hosts"})
+	godif.ProvideKeyValue(&Keys, "debug", &Key{Target: &cfg.debug, Default: false, Description: "Verbose | debug output"})
+}
+
+func TestResolveAllDataJSON(t *testing.T) {
+	godif.Reset()
+	defer godif.Reset()
+	var cfg httpConfig
+	var addr func() string
+	declareHTTP(&cfg)
+	godif.Require(&addr)
+	godif.ProvideConstructor(&addr, func() func() string {
+		// config is applied before constructors
+		port := cfg.port
+		return func() string { return fmt.Sprint(":", port) }
+	})
+
+	errs := ResolveAllData([]byte(`{"http": {"port": 9090, "timeout": "30s", "hosts": ["a.example", "b.example"]}}`))
+	require.Nil(t, errs)
+	require.Equal(t, httpConfig{9090, 30 * time.Second, []string{"a.example", "b.example"}, false}, cfg)
+	require.Equal(t, ":9090", addr())
+}
+
+func TestResolveAllYAMLLike(t *testing.T) {
+	godif.Reset()
+	defer godif.Reset()
+	var cfg httpConfig
+	declareHTTP(&cfg)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.Nil(t, os.WriteFile(path, []byte(strings.Join([]string{
+		"# comment",
+		"http:",
+		"  timeout: 1m # one minute",
+		"  hosts: 'a.example, b.example'",
+		"debug: true",
+	}, "\n")), 0600))
+
+	errs := ResolveAll(path)
+	require.Nil(t, errs)
+	// port gets the default
+	require.Equal(t, httpConfig{8080, time.Minute, []string{"a.example", "b.example"}, true}, cfg)
+}
+
+func TestResolveAllDataLists(t *testing.T) {
+	for _, data := range []string{
+		"http:\n  timeout: 1s\n  hosts:\n    - a.example # first\n    - 'b # example'\n",
+		"http:\n  timeout: 1s\n  hosts:\n  - a.example\n  - 'b # example'\n",
+		"http:\n  timeout: 1s\n  hosts: [a.example, 'b # example'] # flow list\n",
+	} {
+		godif.Reset()
+		var cfg httpConfig
+		declareHTTP(&cfg)
+		errs := ResolveAllData([]byte(data))
+		require.Nil(t, errs, data)
+		require.Equal(t, []string{"a.example", "b # example"}, cfg.hosts, data)
+	}
+
+	godif.Reset()
+	defer godif.Reset()
+	var cfg httpConfig
+	declareHTTP(&cfg)
+	errs := ResolveAllData([]byte("http:\n  timeout: 1s\n  hosts: []\n"))
+	require.Nil(t, errs)
+	require.Equal(t, []string{}, cfg.hosts)
+}
+
+func TestResolveAllDataErrors(t *testing.T) {
+	godif.Reset()
+	defer godif.Reset()
+	var cfg httpConfig
+	declareHTTP(&cfg)
+
+	errs := ResolveAllData([]byte("http:\n  port: 70000\n  hosts: a\nunknown: 1\n"))
+	require.Equal(t, 3, len(errs))
+	if e, ok := errs[0].(*EInvalidValue); ok {
+		require.Equal(t, "http.port", e.Name)
+		require.Equal(t, testPkg, e.Package)
+		require.Equal(t, "70000", e.Value)
+	} else {
+		t.Fatal(errs)
+	}
+	if e, ok := errs[1].(*EKeyNotDeclared); ok {
+		require.Equal(t, "unknown", e.Name)
+	} else {
+		t.Fatal(errs)
+	}
+	if e, ok := errs[2].(*EValueNotProvided); ok {
+		require.Equal(t, "http.timeout", e.Name)
+	} else {
+		t.Fatal(errs)
+	}
+	// nothing is assigned
+	require.Nil(t, cfg.hosts)
+
+	errs = ResolveAllData([]byte(`{"http": {"timeout": "soon"}}`))
+	if e, ok := errs[0].(*EInvalidValue); ok && len(errs) == 1 {
+		require.Equal(t, "http.timeout", e.Name)
+	} else {
+		t.Fatal(errs)
+	}
+
+	errs = ResolveAllData([]byte("http:\n  timeout: [1s, 2s]\n"))
+	if e, ok := errs[0].(*EInvalidValue); ok && len(errs) == 1 {
+		require.Equal(t, "[1s, 2s]", e.Value)
+	} else {
+		t.Fatal(errs)
+	}
+
+	for data, line := range map[string]int{
+		"http\n":                    1,
+		"- a\n":                     1,
+		"http:\n  port: 1\n  - a\n": 3,
+		"http:\n  hosts:\n    - a\n    port: 1\n": 4,
+		"debug: true\ndebug: false\n":             2,
+		`{"http.port": 1, "http": {"port": 2}}`:   0,
+		`{"port": 1, "port": 2}`:                  0,
+		`{"http": {"port": 1, "port": 2}}`:        0,
+	} {
+		errs = ResolveAllData([]byte(data))
+		if e, ok := errs[0].(*EInvalidConfig); ok && len(errs) == 1 {
+			require.Equal(t, line, e.Line, data)
+		} else {
+			t.Fatal(data, errs)
+		}
+	}
+}
+
+func TestResolveAllDataResolvePlace(t *testing.T) {
+	godif.Reset()
+	defer godif.Reset()
+	var cfg httpConfig
+	declareHTTP(&cfg)
+
+	_, file, line, _ := runtime.Caller(0)
+	require.Nil(t, ResolveAllData([]byte(`{"http": {"timeout": "1s"}}`)))
+	errs := ResolveAllData([]byte(`{"http": {"timeout": "1s"}}`))
+	if e, ok := errs[0].(*godif.EAlreadyResolved); ok && len(errs) == 1 {
+		require.Equal(t, fmt.Sprintf("Already resolved at %s:%d", file, line+1), e.Error())
+	} else {
+		t.Fatal(errs)
+	}
+
+	godif.Reset()
+	declareHTTP(&cfg)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.Nil(t, os.WriteFile(path, []byte("http:\n  timeout: 1s\n"), 0600))
+	_, file, line, _ = runtime.Caller(0)
+	require.Nil(t, ResolveAll(path))
+	errs = godif.ResolveAll()
+	if e, ok := errs[0].(*godif.EAlreadyResolved); ok && len(errs) == 1 {
+		require.Equal(t, fmt.Sprintf("Already resolved at %s:%d", file, line+1), e.Error())
+	} else {
+		t.Fatal(errs)
+	}
+}
+
+func TestResolveAllDataIn(t *testing.T) {
+	godif.Reset()
+	defer godif.Reset()
+	var port int
+	c := godif.NewContainer()
+	defer c.Reset()
+	DeclareIn(c)
+	c.ProvideKeyValue(&Keys, "port", &Key{Target: &port, Description: "Port"})
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.Nil(t, os.WriteFile(path, []byte("port: 8080\n"), 0600))
+	_, file, line, _ := runtime.Caller(0)
+	require.Nil(t, ResolveAllIn(c, path))
+	require.Equal(t, 8080, port)
+
+	errs := ResolveAllDataIn(c, []byte("port: 9090\n"))
+	if e, ok := errs[0].(*godif.EAlreadyResolved); ok && len(errs) == 1 {
+		require.Equal(t, fmt.Sprintf("Already resolved at %s:%d", file, line+1), e.Error())
+	} else {
+		t.Fatal(errs)
+	}
+	require.Equal(t, 8080, port)
+}
+
+func TestInvalidKey(t *testing.T) {
+	godif.Reset()
+	defer godif.Reset()
+	var port int
+	Declare()
+	godif.ProvideKeyValue(&Keys, "port", &Key{Target: &port, Default: "80"})
+	godif.ProvideKeyValue(&Keys, "name", &Key{Target: "name"})
+
+	errs := ResolveAllData([]byte("{}"))
+	require.Equal(t, 2, len(errs))
+	for _, err := range errs {
+		if _, ok := err.(*EInvalidKey); !ok {
+			t.Fatal(errs)
+		}
+	}
+}
+
+func TestDocs(t *testing.T) {
+	godif.Reset()
+	defer godif.Reset()
+	var cfg httpConfig
+	declareHTTP(&cfg)
+	require.Nil(t, godif.ResolveAll())
+	// plain godif.ResolveAll() does not assign values
+	require.Equal(t, 0, cfg.port)
+
+	require.Equal(t, strings.Join([]string{
+		"| Key | Type | Default | Description | Package |",
+		"|-----|------|---------|-------------|---------|",
+		"| `debug` | `bool` | `false` | Verbose \\| debug output | `" + testPkg + "` |",
+		"| `http.hosts` | `[]string` | `[localhost]` | Allowed hosts | `" + testPkg + "` |",
+		"| `http.port` | `int` | `8080` | HTTP port | `" + testPkg + "` |",
+		"| `http.timeout` | `time.Duration` | *required* | Request timeout | `" + testPkg + "` |",
+		"",
+	}, "\n"), Docs())
+}
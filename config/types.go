@@ -0,0 +1,20 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package config
+
+// Key declares a config key
+type Key struct {
+	// Target receives the value, its type is the type of the key, e.g. &port for int key
+	Target interface{}
+	// Default is used if the key is missing in config, nil means the key is mandatory
+	Default interface{}
+	// Description goes to the docs
+	Description string
+	// Validate checks the value (loaded or default one), nil means any value of the type is valid
+	Validate func(value interface{}) error
+}
@@ -0,0 +1,285 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/untillpro/gochips/errs"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// ProvideConstructor registers constructor of ref implementation, e.g. func(dep1 T1, dep2 T2) (T, error)
+// Constructor parameters are resolved by type from provided func, interface and pointer targets
+// Constructors are called by ResolveAll() in dependency order, errors returned by constructors are returned by ResolveAll()
+// Constructors are called while the container is locked, so calls of the container, e.g. IsProvided() or MustGet(), give EReentrantCall
+func (c *Container) ProvideConstructor(ref interface{}, constructor interface{}) {
+	c.provideConstructor(ref, constructor)
+}
+
+// ProvideConstructor registers constructor of ref implementation in the default container
+func ProvideConstructor(ref interface{}, constructor interface{}) {
+	defaultContainer.provideConstructor(ref, constructor)
+}
+
+func (c *Container) provideConstructor(ref interface{}, constructor interface{}) {
+	prov := callerSrcPkgElem(3, constructor)
+	prov.constructor = true
+	c.addProvision(ref, prov)
+}
+
+// implType returns type of the value which is injected by the provision, nil for invalid constructors and lazy factories
+func (p *srcPkgElem) implType() reflect.Type {
+	t := reflect.TypeOf(p.elem)
+	switch {
+	case p.lazy && !isLazyFactory(t, p.holder):
+		return nil
+	case p.lazy && p.holder != nil:
+		return p.holder
+	case !p.lazy && !p.constructor:
+		return t
+	case !isConstructor(t):
+		return nil
+	}
+	return t.Out(0)
+}
+
+func isConstructor(t reflect.Type) bool {
+	if t == nil || t.Kind() != reflect.Func {
+		return false
+	}
+	switch t.NumOut() {
+	case 1:
+		return true
+	case 2:
+		return t.Out(1) == errorType
+	}
+	return false
+}
+
+// targetsOfType returns provided func, interface and pointer targets of the given type
+func (c *Container) targetsOfType(t reflect.Type) (res []interface{}) {
+	if !isService(t.Kind()) {
+		return nil
+	}
+	found := make(map[interface{}]bool)
+	for cur := c; cur != nil; cur = cur.parent {
+		for target := range cur.provided {
+			if found[target] || reflect.TypeOf(target).Elem() != t || c.effectiveProvided(target) == nil {
+				continue
+			}
+			found[target] = true
+			res = append(res, target)
+		}
+	}
+	return res
+}
+
+// constructorDeps returns effective provisions of constructor parameters, nil if some parameter is not resolved
+func (c *Container) constructorDeps(prov *srcPkgElem) (deps []*srcPkgElem) {
+	ctorType := reflect.TypeOf(prov.elem)
+	for i := 0; i < ctorType.NumIn(); i++ {
+		targets := c.targetsOfType(ctorType.In(i))
+		if len(targets) != 1 {
+			return nil
+		}
+		deps = append(deps, c.effectiveProvided(targets[0])[0])
+	}
+	return deps
+}
+
+func (c *Container) validateConstructors(requiredPackages map[string]bool) (errs errs.Errors) {
+	var ctors []*srcPkgElem
+	for _, target := range targetsInProvisionOrder(c.provided) {
+		for _, prov := range c.selectProvs(target, c.provided[target]) {
+			if !prov.constructor || c.resolvedProvs[prov] {
+				continue
+			}
+			if !isConstructor(reflect.TypeOf(prov.elem)) || !isService(reflect.TypeOf(target).Elem().Kind()) {
+				errs.AddE(&EInvalidConstructor{prov})
+				continue
+			}
+			ctorType := reflect.TypeOf(prov.elem)
+			resolved := true
+			for i := 0; i < ctorType.NumIn(); i++ {
+				paramType := ctorType.In(i)
+				targets := c.targetsOfType(paramType)
+				if len(targets) != 1 {
+					var candidates []*srcPkgElem
+					for _, target := range targets {
+						candidates = append(candidates, c.effectiveProvided(target)[0])
+					}
+					errs.AddE(&EConstructorDependencyNotResolved{prov, paramType, sortSrcs(candidates)})
+					resolved = false
+					continue
+				}
+				// constructor requires packages of its dependencies
+				requiredPackages[c.effectiveProvided(targets[0])[0].pkg] = true
+			}
+			if resolved {
+				ctors = append(ctors, prov)
+			}
+		}
+	}
+
+	// cycles are looked for among constructors with resolved parameters only
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[*srcPkgElem]int)
+	var stack []*srcPkgElem
+	var visit func(prov *srcPkgElem)
+	visit = func(prov *srcPkgElem) {
+		state[prov] = visiting
+		stack = append(stack, prov)
+		for _, dep := range c.constructorDeps(prov) {
+			if !dep.constructor || !isConstructor(reflect.TypeOf(dep.elem)) {
+				continue
+			}
+			switch state[dep] {
+			case visiting:
+				for i := range stack {
+					if stack[i] == dep {
+						errs.AddE(&ECyclicDependency{append([]*srcPkgElem{}, stack[i:]...)})
+						break
+					}
+				}
+			case 0:
+				visit(dep)
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[prov] = visited
+	}
+	for _, ctor := range ctors {
+		if state[ctor] == 0 {
+			visit(ctor)
+		}
+	}
+	return errs
+}
+
+// callProvided calls constructor or loader, call of the container which is being resolved is reported as EReentrantCall
+func callProvided(prov *srcPkgElem, args []reflect.Value) (res []reflect.Value, reentrant *EReentrantCall) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(*EReentrantCall)
+			if !ok {
+				panic(r)
+			}
+			e.prov = prov
+			reentrant = e
+		}
+	}()
+	return reflect.ValueOf(prov.elem).Call(args), nil
+}
+
+// runConstructors calls constructors of targets which will be injected
+func (c *Container) runConstructors() (errs errs.Errors) {
+	required := c.requiredView()
+	failed := make(map[*srcPkgElem]bool)
+	var run func(prov *srcPkgElem) bool
+	run = func(prov *srcPkgElem) bool {
+		if _, ok := c.lookupConstructed(prov); ok {
+			return true
+		}
+		if failed[prov] {
+			return false
+		}
+		ctorType := reflect.TypeOf(prov.elem)
+		deps := c.constructorDeps(prov)
+		args := make([]reflect.Value, ctorType.NumIn())
+		for i, dep := range deps {
+			if dep.constructor && !run(dep) {
+				failed[prov] = true
+				return false
+			}
+			args[i] = c.decoratedValue(c.targetsOfType(ctorType.In(i))[0], dep)
+		}
+		res, reentrant := callProvided(prov, args)
+		if reentrant != nil {
+			errs.AddE(reentrant)
+			failed[prov] = true
+			return false
+		}
+		if len(res) == 2 && !res[1].IsNil() {
+			errs.AddE(&EConstructorFailed{prov, res[1].Interface().(error)})
+			failed[prov] = true
+			return false
+		}
+		c.constructed[prov] = res[0]
+		return true
+	}
+
+	needed := func(target interface{}) bool {
+		if _, ok := required[target]; ok {
+			return true
+		}
+		if _, ok := c.keyValues[target]; ok {
+			return true
+		}
+		_, ok := c.sliceElements[target]
+		return ok
+	}
+	var toRun []*srcPkgElem
+	for target := range c.provided {
+		if needed(target) {
+			if prov := c.effectiveProvided(target)[0]; prov.constructor {
+				toRun = append(toRun, prov)
+			}
+		}
+	}
+	for target := range required {
+		if provs := c.effectiveProvided(target); provs != nil && provs[0].constructor {
+			toRun = append(toRun, provs[0])
+		}
+	}
+	// constructors which do not depend on each other are called in provision order
+	sort.Slice(toRun, func(i, j int) bool {
+		return toRun[i].seq < toRun[j].seq
+	})
+	for _, prov := range toRun {
+		run(prov)
+	}
+	return errs
+}
+
+// lookupConstructed returns value constructed by the container or by the nearest ancestor
+func (c *Container) lookupConstructed(prov *srcPkgElem) (reflect.Value, bool) {
+	for cur := c; cur != nil; cur = cur.parent {
+		if v, ok := cur.constructed[prov]; ok {
+			return v, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// implValue returns value which is injected by the provision
+func (c *Container) implValue(prov *srcPkgElem) reflect.Value {
+	if prov.constructor {
+		v, _ := c.lookupConstructed(prov)
+		return v
+	}
+	if prov.lazy {
+		// trampoline or holder is created once, so the factory is called once for the container and its children
+		v, ok := c.lookupConstructed(prov)
+		if !ok {
+			if prov.holder != nil {
+				v = prov.newLazyHolder()
+			} else {
+				v = prov.lazyTrampoline()
+			}
+			c.constructed[prov] = v
+		}
+		return v
+	}
+	return reflect.ValueOf(prov.elem)
+}
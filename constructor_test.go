@@ -0,0 +1,213 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type ctorService struct {
+	store IStore
+	cfg   *config
+}
+
+func TestConstructorBasic(t *testing.T) {
+	Reset()
+	var store IStore
+	var cfg *config
+	var service *ctorService
+	var calls []string
+
+	Require(&service)
+	ProvideConstructor(&service, func(store IStore, cfg *config) *ctorService {
+		calls = append(calls, "service")
+		return &ctorService{store, cfg}
+	})
+	ProvideConstructor(&store, func(cfg *config) (IStore, error) {
+		calls = append(calls, "store")
+		return &memStore{data: map[string]string{"name": cfg.Name}}, nil
+	})
+	Provide(&cfg, &config{Name: "name"})
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, []string{"store", "service"}, calls)
+	require.Equal(t, "name", service.store.Get("name"))
+	require.Equal(t, "name", service.cfg.Name)
+
+	// dependencies are not injected if not required
+	require.Nil(t, store)
+	require.Nil(t, cfg)
+
+	Reset()
+	require.Nil(t, service)
+}
+
+func TestConstructorCalledOnce(t *testing.T) {
+	Reset()
+	var store IStore
+	var getter func() IStore
+	calls := 0
+
+	Require(&store)
+	Require(&getter)
+	ProvideConstructor(&store, func() IStore {
+		calls++
+		return &memStore{}
+	})
+	ProvideConstructor(&getter, func(store IStore) func() IStore {
+		return func() IStore { return store }
+	})
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 1, calls)
+	require.True(t, store == getter())
+}
+
+func TestConstructorErrorOnFailure(t *testing.T) {
+	Reset()
+	var store IStore
+	var cfg *config
+
+	Require(&store)
+	Require(&cfg)
+	Provide(&cfg, &config{})
+	_, file, line, _ := runtime.Caller(0)
+	ProvideConstructor(&store, func() (IStore, error) {
+		return nil, errors.New("no connection")
+	})
+
+	errs := ResolveAll()
+	if e, ok := errs[0].(*EConstructorFailed); ok && len(errs) == 1 {
+		fmt.Println(errs)
+		require.Equal(t, file, e.prov.file)
+		require.Equal(t, line+1, e.prov.line)
+		require.Equal(t, "no connection", e.err.Error())
+	} else {
+		t.Fatal(errs)
+	}
+	require.Nil(t, store)
+	require.Nil(t, cfg)
+}
+
+func TestConstructorErrorOnCycle(t *testing.T) {
+	Reset()
+	var store IStore
+	var cfg *config
+	var service *ctorService
+
+	Require(&service)
+	ProvideConstructor(&service, func(store IStore) *ctorService { return nil })
+	_, file1, line1, _ := runtime.Caller(0)
+	ProvideConstructor(&store, func(cfg *config) IStore { return nil })
+	_, file2, line2, _ := runtime.Caller(0)
+	ProvideConstructor(&cfg, func(store IStore) *config { return nil })
+
+	errs := ResolveAll()
+	if e, ok := errs[0].(*ECyclicDependency); ok && len(errs) == 1 {
+		fmt.Println(errs)
+		require.Equal(t, 2, len(e.steps))
+		locations := map[string]bool{}
+		for _, step := range e.steps {
+			locations[fmt.Sprintf("%s:%d", step.file, step.line)] = true
+		}
+		require.True(t, locations[fmt.Sprintf("%s:%d", file1, line1+1)])
+		require.True(t, locations[fmt.Sprintf("%s:%d", file2, line2+1)])
+	} else {
+		t.Fatal(errs)
+	}
+	require.Nil(t, service)
+}
+
+func TestConstructorErrorOnDependencyNotResolved(t *testing.T) {
+	Reset()
+	var service *ctorService
+	var store1 IStore
+	var store2 IStore
+
+	Require(&service)
+	ProvideConstructor(&service, func(store IStore, cfg *config) *ctorService { return nil })
+	Provide(&store1, &memStore{})
+	Provide(&store2, &memStore{})
+
+	errs := ResolveAll()
+	require.Len(t, errs, 2, errs)
+	fmt.Println(errs)
+	for _, err := range errs {
+		e, ok := err.(*EConstructorDependencyNotResolved)
+		require.True(t, ok, err)
+		if e.paramType.String() == "godif.IStore" {
+			require.Len(t, e.candidates, 2)
+		} else {
+			require.Len(t, e.candidates, 0)
+		}
+	}
+}
+
+func TestConstructorErrorOnInvalid(t *testing.T) {
+	Reset()
+	var service *ctorService
+	var mySlice []string
+
+	Require(&service)
+	ProvideConstructor(&service, func() (*ctorService, int) { return nil, 0 })
+	ProvideConstructor(&mySlice, func() []string { return nil })
+
+	errs := ResolveAll()
+	require.Len(t, errs, 2, errs)
+	for _, err := range errs {
+		_, ok := err.(*EInvalidConstructor)
+		require.True(t, ok, err)
+	}
+}
+
+func TestConstructorErrorOnIncompatibleTypes(t *testing.T) {
+	Reset()
+	var store IStore
+
+	Require(&store)
+	ProvideConstructor(&store, func() *readOnlyStore { return nil })
+
+	errs := ResolveAll()
+	if _, ok := errs[0].(*EInterfaceNotImplemented); ok && len(errs) == 1 {
+		fmt.Println(errs)
+	} else {
+		t.Fatal(errs)
+	}
+}
+
+func TestConstructorErrorOnReentrantCall(t *testing.T) {
+	c := NewContainer()
+	var injectedFunc func(x int, y int) int
+	var getter func() int
+
+	c.Require(&getter)
+	c.Provide(&injectedFunc, f)
+	_, _, line, _ := runtime.Caller(0)
+	c.ProvideConstructor(&getter, func(sum func(x int, y int) int) func() int {
+		c.IsProvided(&injectedFunc)
+		return func() int { return sum(3, 2) }
+	})
+
+	errs := c.ResolveAll()
+	if e, ok := errs[0].(*EReentrantCall); ok && len(errs) == 1 {
+		require.Equal(t, line+1, e.prov.line)
+	} else {
+		t.Fatal(errs)
+	}
+	require.Nil(t, getter)
+
+	// container is not locked after the error
+	require.False(t, c.IsProvided(&injectedFunc))
+}
@@ -0,0 +1,628 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/untillpro/gochips/errs"
+)
+
+// Container keeps requirements and provisions and resolves them independently from other containers
+// Container is safe for concurrent use
+type Container struct {
+	mu              sync.Mutex
+	parent          *Container
+	saved           map[interface{}]reflect.Value
+	selections      map[interface{}]*selection
+	injected        map[interface{}]*srcPkgElem
+	constructed     map[*srcPkgElem]reflect.Value
+	decorators      map[interface{}][]*decorator
+	optional        map[interface{}]bool
+	typeTargets     map[reflect.Type]interface{}
+	required        map[interface{}]*srcElem
+	provided        map[interface{}][]*srcPkgElem
+	profiled        map[interface{}][]*srcPkgElem
+	profiles        map[string]bool
+	keyValues       map[interface{}]map[interface{}][]*srcElem
+	keyOrder        map[interface{}][]interface{}
+	sliceElements   map[interface{}][]*srcElem
+	elementOrders   map[*srcElem]*ElementOrder
+	bindings        map[interface{}][]*binding
+	loaders         []*loader
+	bound           map[interface{}]bool
+	resolveSrc      *src
+	resolvedChild   *Container
+	resolvingG      int64
+	resolvedProvs   map[*srcPkgElem]bool
+	resolvedElems   map[*srcElem]bool
+	resolvedReqs    map[interface{}]bool
+	unhashableProvs []*src
+	unhashableReqs  []*src
+}
+
+// lock locks the container, call from the goroutine which resolves the container panics with EReentrantCall instead of deadlock
+func (c *Container) lock() {
+	if c.mu.TryLock() {
+		return
+	}
+	if g := atomic.LoadInt64(&c.resolvingG); g != 0 && g == goroutineID() {
+		panic(&EReentrantCall{})
+	}
+	c.mu.Lock()
+}
+
+// setResolving marks the container and its ancestors as locked by goroutine g, 0 clears the mark
+func (c *Container) setResolving(g int64) {
+	for cur := c; cur != nil; cur = cur.parent {
+		atomic.StoreInt64(&cur.resolvingG, g)
+	}
+}
+
+// NewContainer creates an empty container
+func NewContainer() *Container {
+	c := &Container{}
+	c.reset()
+	return c
+}
+
+// Reset clears all assignations made by the container
+func (c *Container) Reset() {
+	c.lock()
+	defer c.mu.Unlock()
+	for p := c.parent; p != nil; p = p.parent {
+		p.lock()
+		defer p.mu.Unlock()
+	}
+	c.reset()
+}
+
+func (c *Container) reset() {
+	if c.parent != nil {
+		c.restoreTargets()
+		if c.parent.resolvedChild == c {
+			c.parent.resolvedChild = nil
+		}
+	} else {
+		c.zeroTargets()
+	}
+	c.resolveSrc = nil
+	c.resolvedChild = nil
+	c.resolvedProvs = make(map[*srcPkgElem]bool)
+	c.resolvedElems = make(map[*srcElem]bool)
+	c.resolvedReqs = make(map[interface{}]bool)
+	c.saved = make(map[interface{}]reflect.Value)
+	c.selections = make(map[interface{}]*selection)
+	c.injected = make(map[interface{}]*srcPkgElem)
+	c.constructed = make(map[*srcPkgElem]reflect.Value)
+	c.decorators = make(map[interface{}][]*decorator)
+	c.optional = make(map[interface{}]bool)
+	if c.typeTargets == nil {
+		// hidden targets are kept on Reset(), they are zeroed as any other target
+		c.typeTargets = make(map[reflect.Type]interface{})
+	}
+	c.unhashableProvs = []*src{}
+	c.unhashableReqs = []*src{}
+	c.required = map[interface{}]*srcElem{}
+	c.provided = make(map[interface{}][]*srcPkgElem)
+	c.profiled = make(map[interface{}][]*srcPkgElem)
+	c.profiles = nil
+	c.keyValues = make(map[interface{}]map[interface{}][]*srcElem)
+	c.keyOrder = make(map[interface{}][]interface{})
+	c.sliceElements = make(map[interface{}][]*srcElem)
+	c.elementOrders = make(map[*srcElem]*ElementOrder)
+	c.bindings = make(map[interface{}][]*binding)
+	c.bound = make(map[interface{}]bool)
+	c.loaders = nil
+}
+
+func (c *Container) zeroTargets() {
+	for target := range c.bound {
+		v := reflect.ValueOf(target).Elem()
+		v.Set(reflect.Zero(v.Type()))
+	}
+	for target, r := range c.required {
+		if _, ok := c.bindings[target]; ok && c.injected[target] == nil {
+			// bound target without value is left as is, like manually inited var
+			continue
+		}
+		v := reflect.ValueOf(r.elem)
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+			if v.CanSet() {
+				v.Set(reflect.Zero(v.Type()))
+			}
+		}
+	}
+	for p := range c.provided {
+		if _, ok := c.required[p]; !ok {
+			if _, ok := c.keyValues[p]; !ok {
+				if _, ok := c.sliceElements[p]; !ok {
+					continue
+				}
+			}
+		}
+		v := reflect.ValueOf(p)
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+			if v.CanSet() {
+				v.Set(reflect.Zero(v.Type()))
+			}
+		}
+	}
+}
+
+// ProvideSliceElement s.e.
+func (c *Container) ProvideSliceElement(pointerToSlice interface{}, element interface{}) {
+	c.provideSliceElement(pointerToSlice, element)
+}
+
+// ProvideKeyValue s.e.
+func (c *Container) ProvideKeyValue(pointerToMap interface{}, key interface{}, value interface{}) {
+	c.provideKeyValue(pointerToMap, key, value)
+}
+
+// Provide registers implementation of ref type
+func (c *Container) Provide(ref interface{}, funcImplementation interface{}) {
+	c.provide(ref, funcImplementation)
+}
+
+// Require registers dep
+func (c *Container) Require(toInject interface{}) {
+	c.require(toInject)
+}
+
+// ResolveAll all deps
+func (c *Container) ResolveAll() errs.Errors {
+	return c.resolveAll()
+}
+
+// Validate runs all checks of ResolveAll() without calling constructors and assigning targets, the container stays unresolved
+func (c *Container) Validate() errs.Errors {
+	c.lock()
+	defer c.mu.Unlock()
+	for p := c.parent; p != nil; p = p.parent {
+		p.lock()
+		defer p.mu.Unlock()
+	}
+	if c.resolveSrc == nil {
+		c.activateProfiles(Options{}.activeProfiles())
+	}
+	if errs := c.validate(Options{}); errs != nil {
+		return sortErrors(errs)
+	}
+	return nil
+}
+
+// Methods below are called from exported methods of Container and from package-level wrappers only,
+// so the caller of the public API is always two frames up
+
+func (c *Container) provideSliceElement(pointerToSlice interface{}, element interface{}) {
+	pkg, file, line := caller(2)
+	c.addSliceElement(pointerToSlice, newSrcElem(file, line, pkg, element), nil)
+}
+
+func (c *Container) addSliceElement(pointerToSlice interface{}, srcElement *srcElem, order *ElementOrder) {
+	c.lock()
+	defer c.mu.Unlock()
+	if isHashable(pointerToSlice) {
+		c.sliceElements[pointerToSlice] = append(c.sliceElements[pointerToSlice], srcElement)
+		if order != nil {
+			c.elementOrders[srcElement] = order
+		}
+	} else {
+		c.unhashableProvs = append(c.unhashableProvs, srcElement.src)
+	}
+}
+
+func (c *Container) provideKeyValue(pointerToMap interface{}, key interface{}, value interface{}) {
+	pkg, file, line := caller(2)
+	c.lock()
+	defer c.mu.Unlock()
+	srcElement := newSrcElem(file, line, pkg, value)
+	if isHashable(pointerToMap) {
+		if c.keyValues[pointerToMap] == nil {
+			c.keyValues[pointerToMap] = make(map[interface{}][]*srcElem)
+		}
+		if _, ok := c.keyValues[pointerToMap][key]; !ok {
+			c.keyOrder[pointerToMap] = append(c.keyOrder[pointerToMap], key)
+		}
+		c.keyValues[pointerToMap][key] = append(c.keyValues[pointerToMap][key], srcElement)
+	} else {
+		c.unhashableProvs = append(c.unhashableProvs, srcElement.src)
+	}
+}
+
+func (c *Container) provide(ref interface{}, funcImplementation interface{}) {
+	c.addProvision(ref, callerSrcPkgElem(3, funcImplementation))
+}
+
+func (c *Container) addProvision(ref interface{}, prov *srcPkgElem) {
+	c.lock()
+	defer c.mu.Unlock()
+	if isHashable(ref) {
+		c.provided[ref] = append(c.provided[ref], prov)
+	} else {
+		c.unhashableProvs = append(c.unhashableProvs, prov.src)
+	}
+}
+
+func (c *Container) require(toInject interface{}) {
+	pkg, file, line := caller(2)
+	c.addRequirement(newSrcElem(file, line, pkg, toInject), false)
+}
+
+// addRequirement keeps requirement mandatory if it is registered both as mandatory and optional
+// Optional requirement does not replace the source of existing one
+func (c *Container) addRequirement(req *srcElem, optional bool) {
+	c.lock()
+	defer c.mu.Unlock()
+	if isHashable(req.elem) {
+		if _, ok := c.optional[req.elem]; !ok || !optional {
+			c.required[req.elem] = req
+			c.optional[req.elem] = optional
+		}
+	} else {
+		c.unhashableReqs = append(c.unhashableReqs, req.src)
+	}
+}
+
+// resolveAll holds the lock during validation and injection, so it works with a consistent snapshot of provisions
+func (c *Container) resolveAll() errs.Errors {
+	c.lock()
+	defer c.mu.Unlock()
+	for p := c.parent; p != nil; p = p.parent {
+		p.lock()
+		defer p.mu.Unlock()
+	}
+
+	if errs, _ := c.resolve(Options{}); errs != nil {
+		return errs
+	}
+
+	_, file, line := caller(2)
+	c.resolveSrc = &src{file, line}
+
+	return nil
+}
+
+// resolve returns errors and warnings of validation, targets are injected if there are no errors
+func (c *Container) resolve(opts Options) (errs.Errors, errs.Errors) {
+	if c.resolveSrc == nil {
+		c.activateProfiles(opts.activeProfiles())
+	}
+	errs, warnings := opts.split(c.validate(opts))
+	if errs != nil {
+		return sortErrors(errs), sortErrors(warnings)
+	}
+
+	// constructors and loaders must not call the container which is locked
+	c.setResolving(goroutineID())
+	defer c.setResolving(0)
+	if errs := c.runLoaders(); errs != nil {
+		return sortErrors(errs), sortErrors(warnings)
+	}
+
+	if errs := c.runConstructors(); errs != nil {
+		return sortErrors(errs), sortErrors(warnings)
+	}
+
+	if c.parent != nil {
+		c.injectChild()
+	} else {
+		c.inject()
+	}
+	c.injectBindings()
+	c.markResolved()
+	if c.parent != nil {
+		c.parent.resolvedChild = c
+	}
+
+	return nil, sortErrors(warnings)
+}
+
+func (c *Container) inject() {
+	for target, provVar := range c.provided {
+		// implementation and key-value provided -> consider implicitly required. Will initialize.
+		if _, ok := c.required[target]; !ok {
+			if _, ok := c.keyValues[target]; !ok {
+				if _, ok := c.sliceElements[target]; !ok {
+					continue
+				}
+			}
+		}
+		if targetValue := reflect.ValueOf(target).Elem(); isEmpty(targetValue) {
+			prov := c.selectProvs(target, provVar)[0]
+			targetValue.Set(c.decoratedValue(target, prov))
+			c.injected[target] = prov
+		}
+	}
+
+	for targetMap, kvToAppend := range c.keyValues {
+		appendKeyValues(reflect.ValueOf(targetMap).Elem(), kvToAppend, c.keyOrder[targetMap])
+	}
+
+	for targetSlice, elementsToAppend := range c.sliceElements {
+		appendSliceElements(reflect.ValueOf(targetSlice).Elem(), c.orderedSliceElements(elementsToAppend))
+	}
+}
+
+// appendKeyValues sets keys in provision order, values of key-slices are appended in provision order
+func appendKeyValues(targetMapValue reflect.Value, kvToAppend map[interface{}][]*srcElem, keys []interface{}) {
+	tragetMapValueType := targetMapValue.Type().Elem()
+	tragetMapValueKind := tragetMapValueType.Kind()
+	for _, k := range keys {
+		v := kvToAppend[k]
+		keyValue := reflect.ValueOf(k)
+		var toAppendValue reflect.Value
+		if isSlice(tragetMapValueKind) {
+			existingSlice := targetMapValue.MapIndex(keyValue)
+			newSlice := reflect.New(reflect.SliceOf(tragetMapValueType.Elem())).Elem()
+			if existingSlice.IsValid() {
+				for i := 0; i < existingSlice.Len(); i++ {
+					existingElement := existingSlice.Index(i)
+					newSlice.Set(reflect.Append(newSlice, existingElement))
+				}
+			}
+			for _, elementToAppend := range v {
+				elementToAppendValue := reflect.ValueOf(elementToAppend.elem)
+				elementToAppendKind := elementToAppendValue.Kind()
+				if isSlice(elementToAppendKind) {
+					for i := 0; i < elementToAppendValue.Len(); i++ {
+						newSlice.Set(reflect.Append(newSlice, elementToAppendValue.Index(i)))
+					}
+				} else {
+					newSlice.Set(reflect.Append(newSlice, elementToAppendValue))
+				}
+			}
+			toAppendValue = newSlice
+		} else {
+			toAppendValue = reflect.ValueOf(v[0].elem)
+		}
+		targetMapValue.SetMapIndex(keyValue, toAppendValue)
+	}
+}
+
+func appendSliceElements(targetSliceValue reflect.Value, elementsToAppend []*srcElem) {
+	for _, elementToAppend := range elementsToAppend {
+		elementValue := reflect.ValueOf(elementToAppend.elem)
+		elementKind := elementValue.Kind()
+		if isSlice(elementKind) {
+			for i := 0; i < elementValue.Len(); i++ {
+				targetSliceValue.Set(reflect.Append(targetSliceValue, elementValue.Index(i)))
+			}
+		} else {
+			targetSliceValue.Set(reflect.Append(targetSliceValue, elementValue))
+		}
+	}
+}
+
+// validate uses opts only to skip checks which are senseless for errors reported with SeverityError
+func (c *Container) validate(opts Options) (errs errs.Errors) {
+	if c.resolveSrc != nil {
+		return errs.AddE(&EAlreadyResolved{c.resolveSrc})
+	}
+
+	if c.parent != nil && c.parent.resolveSrc == nil {
+		return errs.AddE(&EParentNotResolved{})
+	}
+
+	if c.parent != nil && c.parent.resolvedChild != nil && c.parent.resolvedChild != c {
+		return errs.AddE(&ESiblingResolved{c.parent.resolvedChild.resolveSrc})
+	}
+
+	requiredPackages := make(map[string]bool)
+
+	if errs = c.validateHashable(); errs != nil {
+		return errs
+	}
+
+	errs = append(errs, c.validateOverrides()...)
+
+	required := c.requiredView()
+
+	for target, sel := range c.selections {
+		if _, ok := required[target]; ok || c.lookupSelection(target) == nil {
+			continue
+		}
+		if c.effectiveProvided(target) == nil {
+			errs.AddE(&ENamedImplementationNotProvided{sel, target})
+		}
+	}
+
+	for _, req := range required {
+		errs = append(errs, c.validateRequirement(req, requiredPackages)...)
+	}
+
+	for targetMap, kvToAppend := range c.keyValues {
+		targetMapType := reflect.TypeOf(targetMap).Elem()
+		targetMapValue := reflect.ValueOf(targetMap).Elem()
+		impl := c.selectProvs(targetMap, c.provided[targetMap])
+		if targetMapValue.IsNil() {
+			if c.effectiveProvided(targetMap) == nil {
+				// ENamedImplementationNotProvided is already reported if something is selected
+				if c.lookupSelection(targetMap) == nil {
+					errs.AddE(&EImplementationNotProvided{kvToAppend[c.keyOrder[targetMap][0]][0], targetMap})
+				}
+				continue
+			}
+		} else {
+			if impl != nil {
+				errs.AddE(&EImplementationProvidedForNonNil{impl[0]})
+				// types are validated only if the error is downgraded by Options, so injection is safe
+				if opts.Severities["EImplementationProvidedForNonNil"] == SeverityError {
+					continue
+				}
+			}
+		}
+		errs = append(errs, validateKeyValueTypes(targetMapType, kvToAppend)...)
+	}
+
+	for targetSlice, elementsToAppend := range c.sliceElements {
+		errs = append(errs, c.validateSliceElements(reflect.TypeOf(targetSlice).Elem(), elementsToAppend)...)
+	}
+
+	errs = append(errs, c.validateConstructors(requiredPackages)...)
+	errs = append(errs, c.validateLazy()...)
+	errs = append(errs, c.validateDecorators()...)
+	errs = append(errs, c.validateBindings()...)
+	errs = append(errs, c.validateLoaders()...)
+
+	notUsed := make(map[string]map[interface{}]*srcPkgElem)
+
+	for provVar, provSrcs := range c.provided {
+		if provSrcs = c.selectProvs(provVar, provSrcs); provSrcs == nil {
+			continue
+		}
+		provKind := reflect.TypeOf(provVar).Elem().Kind()
+		if !isService(provKind) && len(provSrcs) > 1 {
+			errs.AddE(&EMultipleStorageImplementations{provSrcs})
+			continue
+		}
+		provType := reflect.TypeOf(provSrcs[0].elem)
+		targetType := reflect.TypeOf(provVar).Elem()
+		targetKind := targetType.Kind()
+
+		switch targetKind {
+		case reflect.Func, reflect.Interface, reflect.Ptr:
+			if _, required := requiredPackages[provSrcs[0].pkg]; !required {
+				if notUsed[provSrcs[0].pkg] == nil {
+					notUsed[provSrcs[0].pkg] = make(map[interface{}]*srcPkgElem)
+				}
+				notUsed[provSrcs[0].pkg][provVar] = provSrcs[0]
+			}
+		case reflect.Array, reflect.Slice, reflect.Map:
+			if provSrcs[0].constructor || provSrcs[0].lazy {
+				// reported by validateConstructors() or validateLazy()
+				continue
+			}
+			if isSlice(targetKind) {
+				targetSliceValue := reflect.ValueOf(provVar).Elem()
+				if !targetSliceValue.IsNil() {
+					errs.AddE(&EImplementationProvidedForNonNil{provSrcs[0]})
+				}
+			}
+			if !provType.AssignableTo(targetType) {
+				errs.AddE(&EIncompatibleTypesStorageImpl{targetType, provSrcs[0].srcElem})
+			}
+		}
+	}
+
+	for pkg, provs := range notUsed {
+		errs.AddE(c.packageNotUsed(pkg, provs, required))
+	}
+
+	return errs
+}
+
+func (c *Container) validateHashable() (errs errs.Errors) {
+	if len(c.unhashableProvs) > 0 {
+		for _, unhashableProvsSrc := range c.unhashableProvs {
+			errs.AddE(&EProvisionForNonAssignable{unhashableProvsSrc})
+		}
+		return errs
+	}
+
+	if len(c.unhashableReqs) > 0 {
+		for _, unhashableReqSrc := range c.unhashableReqs {
+			errs.AddE(&ENonAssignableRequirement{unhashableReqSrc})
+		}
+	}
+	return errs
+}
+
+// validateRequirement checks provisions of the requirement, packages of the provisions are added to requiredPackages
+func (c *Container) validateRequirement(req *srcElem, requiredPackages map[string]bool) (errs errs.Errors) {
+	impls := c.effectiveProvided(req.elem)
+
+	if nil == impls {
+		if sel := c.lookupSelection(req.elem); sel != nil {
+			errs.AddE(&ENamedImplementationNotProvided{sel, req.elem})
+		} else if !c.isOptional(req.elem) && !c.isBound(req.elem) {
+			errs.AddE(&EImplementationNotProvided{req, nil})
+		}
+	}
+
+	if len(impls) > 1 {
+		errs.AddE(&EMultipleFuncImplementations{req, impls})
+	}
+
+	reqType := reflect.TypeOf(req.elem).Elem()
+
+	for _, impl := range impls {
+		requiredPackages[impl.pkg] = true
+		implType := impl.implType()
+		if implType == nil {
+			// invalid constructor or lazy factory, reported by validateConstructors() or validateLazy()
+			continue
+		}
+		if !implType.AssignableTo(reqType) {
+			switch reqType.Kind() {
+			case reflect.Interface:
+				errs.AddE(&EInterfaceNotImplemented{req, impl, missingMethods(reqType, implType)})
+			case reflect.Ptr:
+				errs.AddE(&EIncompatibleTypesPointer{req, impl})
+			default:
+				errs.AddE(&EIncompatibleTypesFunc{req, impl})
+			}
+		}
+	}
+	return errs
+}
+
+func validateKeyValueTypes(targetMapType reflect.Type, kvToAppend map[interface{}][]*srcElem) (errs errs.Errors) {
+	targetMapKeyType := targetMapType.Key()
+	targetMapValueType := targetMapType.Elem()
+	targetMapValueKind := targetMapValueType.Kind()
+	for k, v := range kvToAppend {
+		if isSlice(targetMapValueKind) {
+			reqMapValueSliceElementType := targetMapValueType.Elem()
+			for _, provElement := range v {
+				provType := reflect.TypeOf(provElement.elem)
+				provKind := provType.Kind()
+				if isSlice(provKind) {
+					provType = provType.Elem()
+				}
+				if !provType.AssignableTo(reqMapValueSliceElementType) {
+					errs.AddE(&EIncompatibleTypesStorageValue{targetMapType, provElement})
+				}
+			}
+		} else {
+			if len(v) > 1 {
+				errs.AddE(&EMultipleValues{v})
+			} else {
+				vType := reflect.TypeOf(v[0].elem)
+				if !vType.AssignableTo(targetMapValueType) {
+					errs.AddE(&EIncompatibleTypesStorageValue{targetMapType, v[0]})
+				}
+				kType := reflect.TypeOf(k)
+				if !kType.AssignableTo(targetMapKeyType) {
+					errs.AddE(&EIncompatibleTypesStorageKey{targetMapType, newSrcElem(v[0].file, v[0].line, v[0].pkg, k)})
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func (c *Container) validateSliceElements(targetSliceType reflect.Type, elementsToAppend []*srcElem) (errs errs.Errors) {
+	for _, v := range elementsToAppend {
+		vType := reflect.TypeOf(v.elem)
+		vKind := vType.Kind()
+		if isSlice(vKind) {
+			vType = vType.Elem()
+		}
+		if !vType.AssignableTo(targetSliceType.Elem()) {
+			errs.AddE(&EIncompatibleTypesStorageValue{targetSliceType, v})
+		}
+	}
+	_, orderErrs := c.orderSliceElements(elementsToAppend)
+	return append(errs, orderErrs...)
+}
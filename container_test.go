@@ -0,0 +1,195 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerBasic(t *testing.T) {
+	t.Parallel()
+	c := NewContainer()
+	var injectedFunc func(x int, y int) int
+	var myMap map[string]int
+	var mySlice []string
+
+	c.Require(&injectedFunc)
+	c.Provide(&injectedFunc, f)
+	c.Provide(&myMap, map[string]int{})
+	c.ProvideKeyValue(&myMap, "key1", 1)
+	c.ProvideSliceElement(&mySlice, "str1")
+
+	errs := c.ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 5, injectedFunc(3, 2))
+	require.Equal(t, map[string]int{"key1": 1}, myMap)
+	require.Equal(t, []string{"str1"}, mySlice)
+
+	c.Reset()
+	require.Nil(t, injectedFunc)
+	require.Nil(t, myMap)
+}
+
+func TestContainersAreIndependent(t *testing.T) {
+	t.Parallel()
+	c1 := NewContainer()
+	c2 := NewContainer()
+	var injectedFunc1 func(x int, y int) int
+	var injectedFunc2 func(x int, y int) int
+
+	c1.Require(&injectedFunc1)
+	c1.Provide(&injectedFunc1, f)
+	c2.Require(&injectedFunc2)
+	c2.Provide(&injectedFunc2, f3)
+
+	require.Nil(t, c1.ResolveAll())
+	require.Nil(t, injectedFunc2)
+	require.Nil(t, c2.ResolveAll())
+	require.Equal(t, 5, injectedFunc1(3, 2))
+	require.Equal(t, 6, injectedFunc2(3, 2))
+
+	c1.Reset()
+	require.Nil(t, injectedFunc1)
+	require.NotNil(t, injectedFunc2)
+}
+
+func TestContainerErrorSrc(t *testing.T) {
+	t.Parallel()
+	c := NewContainer()
+	var injectedFunc func(x int, y int) int
+
+	_, reqFile, reqLine, _ := runtime.Caller(0)
+	c.Require(&injectedFunc)
+	_, implFile, implLine, _ := runtime.Caller(0)
+	c.Provide(&injectedFunc, f2)
+
+	errs := c.ResolveAll()
+	if e, ok := errs[0].(*EIncompatibleTypesFunc); ok && len(errs) == 1 {
+		require.Equal(t, reqFile, e.req.file)
+		require.Equal(t, reqLine+1, e.req.line)
+		require.Equal(t, implFile, e.prov.file)
+		require.Equal(t, implLine+1, e.prov.line)
+		require.Equal(t, "github.com/untillpro/godif", e.prov.pkg)
+	} else {
+		t.Fatal(errs)
+	}
+
+	c.Reset()
+	c.Require(&injectedFunc)
+	c.Provide(&injectedFunc, f)
+	require.Nil(t, c.ResolveAll())
+	_, resolveFile, resolveLine, _ := runtime.Caller(0)
+	errs = c.ResolveAll()
+	if e, ok := errs[0].(*EAlreadyResolved); ok && len(errs) == 1 {
+		require.Equal(t, resolveFile, e.resolvePlace.file)
+		require.Equal(t, resolveLine-1, e.resolvePlace.line)
+	} else {
+		t.Fatal(errs)
+	}
+}
+
+func TestContainerConcurrentProvisions(t *testing.T) {
+	t.Parallel()
+	c := NewContainer()
+	const goroutines = 50
+	var mySlice []int
+	var myMap map[string][]int
+	var funcs [goroutines]func(x int, y int) int
+
+	c.Provide(&myMap, map[string][]int{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c.Require(&funcs[i])
+			c.Provide(&funcs[i], f)
+			c.ProvideSliceElement(&mySlice, i)
+			c.ProvideKeyValue(&myMap, fmt.Sprint("key", i%5), i)
+		}(i)
+	}
+	wg.Wait()
+
+	require.Nil(t, c.ResolveAll())
+	require.Len(t, mySlice, goroutines)
+	require.Len(t, myMap, 5)
+	for i := 0; i < 5; i++ {
+		require.Len(t, myMap[fmt.Sprint("key", i)], goroutines/5)
+	}
+	for i := 0; i < goroutines; i++ {
+		require.Equal(t, 5, funcs[i](3, 2))
+	}
+}
+
+func TestContainerConcurrentProvideAndResolve(t *testing.T) {
+	t.Parallel()
+	c := NewContainer()
+	const goroutines = 50
+	var mySlice []int
+
+	var wg sync.WaitGroup
+	var errsCount int
+	var errsMu sync.Mutex
+	for i := 0; i < goroutines; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			c.ProvideSliceElement(&mySlice, i)
+		}(i)
+		go func() {
+			defer wg.Done()
+			if errs := c.ResolveAll(); errs != nil {
+				errsMu.Lock()
+				errsCount++
+				errsMu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Only the first ResolveAll() succeeds, the rest report EAlreadyResolved
+	require.Equal(t, goroutines-1, errsCount)
+	c.Reset()
+}
+
+func TestContainersResolveInParallel(t *testing.T) {
+	t.Parallel()
+	const containers = 20
+	var funcs [containers]func(x int, y int) int
+	var slices [containers][]string
+
+	// require must not be called from other goroutines, errors are checked by the test goroutine
+	results := make(chan error, containers)
+	for i := 0; i < containers; i++ {
+		go func(i int) {
+			c := NewContainer()
+			c.Require(&funcs[i])
+			c.Provide(&funcs[i], f3)
+			c.ProvideSliceElement(&slices[i], fmt.Sprint("str", i))
+			if errs := c.ResolveAll(); errs != nil {
+				results <- errs
+				return
+			}
+			results <- nil
+		}(i)
+	}
+	for i := 0; i < containers; i++ {
+		require.Nil(t, <-results)
+	}
+
+	for i := 0; i < containers; i++ {
+		require.Equal(t, 6, funcs[i](3, 2))
+		require.Equal(t, []string{fmt.Sprint("str", i)}, slices[i])
+	}
+}
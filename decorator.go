@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/untillpro/gochips/errs"
+)
+
+type decorator struct {
+	*srcPkgElem
+	priority int
+	// stacked is true for layers provided by ProvideStacked()
+	stacked bool
+}
+
+// ProvideDecorator registers decorator of func target, e.g. func(next F) F, with priority 0
+// Decorators are applied by ResolveAll() to the injected implementation by priority ascending, then in provision order,
+// so the decorator applied first is the closest to the implementation
+func (c *Container) ProvideDecorator(ref interface{}, decorator interface{}) {
+	c.provideDecorator(ref, decorator, 0)
+}
+
+// ProvideDecoratorPriority registers decorator of func target with the given priority
+func (c *Container) ProvideDecoratorPriority(ref interface{}, decorator interface{}, priority int) {
+	c.provideDecorator(ref, decorator, priority)
+}
+
+// ProvideDecorator registers decorator of func target in the default container with priority 0
+func ProvideDecorator(ref interface{}, decorator interface{}) {
+	defaultContainer.provideDecorator(ref, decorator, 0)
+}
+
+// ProvideDecoratorPriority registers decorator of func target in the default container with the given priority
+func ProvideDecoratorPriority(ref interface{}, decorator interface{}, priority int) {
+	defaultContainer.provideDecorator(ref, decorator, priority)
+}
+
+func (c *Container) provideDecorator(ref interface{}, dec interface{}, priority int) {
+	prov := callerSrcPkgElem(3, dec)
+	c.lock()
+	defer c.mu.Unlock()
+	if isHashable(ref) {
+		c.decorators[ref] = append(c.decorators[ref], &decorator{srcPkgElem: prov, priority: priority})
+	} else {
+		c.unhashableProvs = append(c.unhashableProvs, prov.src)
+	}
+}
+
+func isDecoratorOf(decType reflect.Type, targetType reflect.Type) bool {
+	return targetType.Kind() == reflect.Func && decType != nil && decType.Kind() == reflect.Func &&
+		decType.NumIn() == 1 && decType.NumOut() == 1 && !decType.IsVariadic() &&
+		targetType.AssignableTo(decType.In(0)) && decType.Out(0).AssignableTo(targetType)
+}
+
+func (c *Container) validateDecorators() (errs errs.Errors) {
+	for target, decs := range c.decorators {
+		targetType := reflect.TypeOf(target).Elem()
+		for _, dec := range decs {
+			if c.resolvedProvs[dec.srcPkgElem] {
+				continue
+			}
+			if isDecoratorOf(reflect.TypeOf(dec.elem), targetType) {
+				continue
+			}
+			if dec.stacked {
+				errs.AddE(&EIncompatibleTypesStackedLayer{targetType, dec.srcPkgElem})
+			} else {
+				errs.AddE(&EIncompatibleTypesDecorator{targetType, dec.srcPkgElem})
+			}
+		}
+	}
+	return errs
+}
+
+// lookupDecorators returns decorators of the container and its ancestors in the order of application
+func (c *Container) lookupDecorators(target interface{}) []*decorator {
+	var res []*decorator
+	for cur := c; cur != nil; cur = cur.parent {
+		res = append(append([]*decorator{}, cur.decorators[target]...), res...)
+	}
+	return sortDecorators(res)
+}
+
+// sortDecorators puts stacked layers first, then decorators by priority, keeping provision order otherwise
+func sortDecorators(decs []*decorator) []*decorator {
+	sort.SliceStable(decs, func(i, j int) bool {
+		if decs[i].stacked != decs[j].stacked {
+			return decs[i].stacked
+		}
+		return !decs[i].stacked && decs[i].priority < decs[j].priority
+	})
+	return decs
+}
+
+// decoratedValue returns value of the provision wrapped by decorators of the target
+func (c *Container) decoratedValue(target interface{}, prov *srcPkgElem) reflect.Value {
+	value := c.implValue(prov)
+	decs := c.lookupDecorators(target)
+	if len(decs) == 0 {
+		return value
+	}
+	return decorate(reflect.TypeOf(target).Elem(), value, decs)
+}
+
+func decorate(targetType reflect.Type, value reflect.Value, decs []*decorator) reflect.Value {
+	value = value.Convert(targetType)
+	for _, dec := range decs {
+		value = reflect.ValueOf(dec.elem).Call([]reflect.Value{value})[0].Convert(targetType)
+	}
+	return value
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func logDecorator(log *[]string, name string) func(next func(x int, y int) int) func(x int, y int) int {
+	return func(next func(x int, y int) int) func(x int, y int) int {
+		return func(x int, y int) int {
+			*log = append(*log, name)
+			return next(x, y)
+		}
+	}
+}
+
+func TestDecoratorOrder(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+	var log []string
+
+	Require(&injectedFunc)
+	ProvideDecorator(&injectedFunc, logDecorator(&log, "first"))
+	ProvideDecoratorPriority(&injectedFunc, logDecorator(&log, "inner"), -1)
+	ProvideDecoratorPriority(&injectedFunc, logDecorator(&log, "outer"), 10)
+	ProvideDecorator(&injectedFunc, logDecorator(&log, "second"))
+	Provide(&injectedFunc, f)
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 5, injectedFunc(3, 2))
+	// the last applied decorator is called first
+	require.Equal(t, []string{"outer", "second", "first", "inner"}, log)
+}
+
+func TestDecoratorChangesResult(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	Require(&injectedFunc)
+	Provide(&injectedFunc, f)
+	ProvideDecorator(&injectedFunc, func(next func(x int, y int) int) func(x int, y int) int {
+		return func(x int, y int) int { return next(x, y) * 10 }
+	})
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 50, injectedFunc(3, 2))
+}
+
+func TestDecoratorErrorOnIncompatibleTypes(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	Require(&injectedFunc)
+	Provide(&injectedFunc, f)
+	_, file, line, _ := runtime.Caller(0)
+	ProvideDecorator(&injectedFunc, func(next func(x float32) float32) func(x float32) float32 { return next })
+
+	errs := ResolveAll()
+	if e, ok := errs[0].(*EIncompatibleTypesDecorator); ok && len(errs) == 1 {
+		require.Equal(t, file, e.prov.file)
+		require.Equal(t, line+1, e.prov.line)
+		require.Equal(t, "github.com/untillpro/godif", e.prov.pkg)
+	} else {
+		t.Fatal(errs)
+	}
+}
+
+func TestDecoratorOfConstructorDependency(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+	var getter func() int
+	var log []string
+
+	Require(&getter)
+	Provide(&injectedFunc, f)
+	ProvideDecorator(&injectedFunc, logDecorator(&log, "decorator"))
+	ProvideConstructor(&getter, func(sum func(x int, y int) int) func() int {
+		return func() int { return sum(3, 2) }
+	})
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 5, getter())
+	require.Equal(t, []string{"decorator"}, log)
+}
+
+func TestDecoratorInChild(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+	var log []string
+
+	Require(&injectedFunc)
+	Provide(&injectedFunc, f)
+	ProvideDecorator(&injectedFunc, logDecorator(&log, "parent"))
+	require.Nil(t, ResolveAll())
+
+	child := NewChild()
+	child.ProvideDecorator(&injectedFunc, logDecorator(&log, "child"))
+	require.Nil(t, child.ResolveAll())
+	require.Equal(t, 5, injectedFunc(3, 2))
+	require.Equal(t, []string{"child", "parent"}, log)
+
+	// child implementation is decorated by decorators of the parent and the child
+	log = nil
+	child2 := NewChild()
+	child2.Provide(&injectedFunc, f3)
+	child2.ProvideDecorator(&injectedFunc, logDecorator(&log, "child2"))
+	child.Reset()
+	require.Nil(t, child2.ResolveAll())
+	require.Equal(t, 6, injectedFunc(3, 2))
+	require.Equal(t, []string{"child2", "parent"}, log)
+
+	child2.Reset()
+	log = nil
+	require.Equal(t, 5, injectedFunc(3, 2))
+	require.Equal(t, []string{"parent"}, log)
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+// ProvideDefault registers fallback implementation of ref type
+// Default implementation is used only if there are no other provisions for ref
+func (c *Container) ProvideDefault(ref interface{}, implementation interface{}) {
+	c.provideDefault(ref, implementation)
+}
+
+// ProvideDefault registers fallback implementation of ref type in the default container
+func ProvideDefault(ref interface{}, implementation interface{}) {
+	defaultContainer.provideDefault(ref, implementation)
+}
+
+func (c *Container) provideDefault(ref interface{}, implementation interface{}) {
+	prov := callerSrcPkgElem(3, implementation)
+	prov.kind = provisionDefault
+	c.addProvision(ref, prov)
+}
+
+// withoutYieldedDefaults removes fallbacks if there are other provisions, then removes defaults if there are regular ones
+func withoutYieldedDefaults(provs []*srcPkgElem) []*srcPkgElem {
+	return withoutKindIfOthers(withoutKindIfOthers(provs, provisionFallback), provisionDefault)
+}
+
+func withoutKindIfOthers(provs []*srcPkgElem, kind provisionKind) []*srcPkgElem {
+	var res []*srcPkgElem
+	for _, prov := range provs {
+		if prov.kind != kind {
+			res = append(res, prov)
+		}
+	}
+	if res == nil {
+		return provs
+	}
+	return res
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultIsUsedIfNothingElseProvided(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	Require(&injectedFunc)
+	_, file, line, _ := runtime.Caller(0)
+	ProvideDefault(&injectedFunc, f)
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 5, injectedFunc(3, 2))
+	require.Equal(t, fmt.Sprintf("func(int, int) int by github.com/untillpro/godif at %s:%d (default)", file, line+1), Report())
+
+	Reset()
+	require.Nil(t, injectedFunc)
+	require.Empty(t, Report())
+}
+
+func TestDefaultYieldsToRegular(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+	var mySlice []string
+
+	Require(&injectedFunc)
+	ProvideDefault(&injectedFunc, f)
+	_, file, line, _ := runtime.Caller(0)
+	Provide(&injectedFunc, f3)
+	Provide(&mySlice, []string{"regular"})
+	ProvideDefault(&mySlice, []string{"default"})
+	ProvideSliceElement(&mySlice, "str")
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 6, injectedFunc(3, 2))
+	require.Equal(t, []string{"regular", "str"}, mySlice)
+	require.Contains(t, Report(), fmt.Sprintf("func(int, int) int by github.com/untillpro/godif at %s:%d", file, line+1))
+	require.NotContains(t, Report(), "(default)")
+}
+
+func TestDefaultCanBeOverridden(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	Require(&injectedFunc)
+	ProvideDefault(&injectedFunc, f)
+	ProvideOverride(&injectedFunc, f3)
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 6, injectedFunc(3, 2))
+	require.Contains(t, Report(), "(override)")
+}
+
+func TestDefaultErrorOnMultipleRegular(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	Require(&injectedFunc)
+	ProvideDefault(&injectedFunc, f)
+	Provide(&injectedFunc, f3)
+	Provide(&injectedFunc, f3)
+
+	errs := ResolveAll()
+	if e, ok := errs[0].(*EMultipleFuncImplementations); ok && len(errs) == 1 {
+		fmt.Println(errs)
+		require.Equal(t, 2, len(e.provs))
+	} else {
+		t.Fatal(errs)
+	}
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"reflect"
+	"sort"
+)
+
+// PackageDependency describes what Requirer needs from Provider
+type PackageDependency struct {
+	Requirer string
+	Provider string
+	// Targets are types of required targets and constructor parameters, sorted by name
+	Targets []reflect.Type
+}
+
+// PackageDependencies returns package-to-package dependency matrix built from requirements and constructor parameters
+// Provider of a requirement is the package of its effective provision, so the matrix is complete for a valid container only
+// The result is sorted by Requirer, then by Provider
+func (c *Container) PackageDependencies() []PackageDependency {
+	c.lock()
+	defer c.mu.Unlock()
+	for p := c.parent; p != nil; p = p.parent {
+		p.lock()
+		defer p.mu.Unlock()
+	}
+	return c.packageDependencies()
+}
+
+// PackageDependencies returns package-to-package dependency matrix of the default container
+func PackageDependencies() []PackageDependency {
+	return defaultContainer.PackageDependencies()
+}
+
+func (c *Container) packageDependencies() (res []PackageDependency) {
+	type edge struct{ requirer, provider string }
+	targets := make(map[edge]map[reflect.Type]bool)
+	add := func(requirer, provider string, t reflect.Type) {
+		e := edge{requirer, provider}
+		if targets[e] == nil {
+			targets[e] = make(map[reflect.Type]bool)
+		}
+		targets[e][t] = true
+	}
+
+	for target, req := range c.requiredView() {
+		for _, prov := range c.effectiveProvided(target) {
+			add(req.pkg, prov.pkg, reflect.TypeOf(target).Elem())
+		}
+	}
+	for target, provs := range c.provided {
+		for _, prov := range c.selectProvs(target, provs) {
+			if !prov.constructor || !isConstructor(reflect.TypeOf(prov.elem)) {
+				continue
+			}
+			ctorType := reflect.TypeOf(prov.elem)
+			for i := 0; i < ctorType.NumIn(); i++ {
+				if deps := c.targetsOfType(ctorType.In(i)); len(deps) == 1 {
+					add(prov.pkg, c.effectiveProvided(deps[0])[0].pkg, ctorType.In(i))
+				}
+			}
+		}
+	}
+
+	for e, types := range targets {
+		dep := PackageDependency{Requirer: e.requirer, Provider: e.provider}
+		for t := range types {
+			dep.Targets = append(dep.Targets, t)
+		}
+		sort.Slice(dep.Targets, func(i, j int) bool {
+			return dep.Targets[i].String() < dep.Targets[j].String()
+		})
+		res = append(res, dep)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Requirer != res[j].Requirer {
+			return res[i].Requirer < res[j].Requirer
+		}
+		return res[i].Provider < res[j].Provider
+	})
+	return res
+}
+
+// packageNotUsed explains EPackageNotUsed: which provisions are not used and which packages require targets of the same types
+func (c *Container) packageNotUsed(pkg string, provs map[interface{}]*srcPkgElem, required map[interface{}]*srcElem) *EPackageNotUsed {
+	res := &EPackageNotUsed{pkgName: pkg}
+	types := make(map[reflect.Type]bool)
+	for target, prov := range provs {
+		res.provs = append(res.provs, prov)
+		types[reflect.TypeOf(target).Elem()] = true
+	}
+	sortSrcs(res.provs)
+	consumers := make(map[string]bool)
+	for target, req := range required {
+		if types[reflect.TypeOf(target).Elem()] && !consumers[req.pkg] {
+			consumers[req.pkg] = true
+			res.consumers = append(res.consumers, req.pkg)
+		}
+	}
+	sort.Strings(res.consumers)
+	return res
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	pluginPkg  = "example.com/plugin"
+	storagePkg = "example.com/storage"
+)
+
+func TestPackageDependencies(t *testing.T) {
+	c := NewContainer()
+	var injectedFunc func(x int, y int) int
+	var store IStore
+	var service *ctorService
+	var cfg *config
+
+	c.Require(&injectedFunc)
+	c.Require(&service)
+	c.addProvision(&injectedFunc, newSrcPkgElem("plugin.go", 1, pluginPkg, f))
+	ctor := newSrcPkgElem("plugin.go", 2, pluginPkg, func(store IStore, cfg *config) *ctorService { return &ctorService{store, cfg} })
+	ctor.constructor = true
+	c.addProvision(&service, ctor)
+	c.addProvision(&store, newSrcPkgElem("storage.go", 1, storagePkg, &memStore{}))
+	c.Provide(&cfg, &config{})
+
+	require.Equal(t, []PackageDependency{
+		{pluginPkg, storagePkg, []reflect.Type{reflect.TypeOf((*IStore)(nil)).Elem()}},
+		{pluginPkg, godifPkg, []reflect.Type{reflect.TypeOf(cfg)}},
+		{godifPkg, pluginPkg, []reflect.Type{reflect.TypeOf(service), reflect.TypeOf(injectedFunc)}},
+	}, c.PackageDependencies())
+	require.Nil(t, c.ResolveAll())
+}
+
+func TestPackageNotUsedExplained(t *testing.T) {
+	c := NewContainer()
+	var injectedFunc func(x int, y int) int
+	var unusedFunc func(x int, y int) int
+	var unusedStore IStore
+
+	c.Require(&injectedFunc)
+	c.Provide(&injectedFunc, f)
+	c.addProvision(&unusedFunc, newSrcPkgElem("plugin.go", 2, pluginPkg, f3))
+	c.addProvision(&unusedStore, newSrcPkgElem("plugin.go", 1, pluginPkg, &memStore{}))
+
+	errs := c.ResolveAll()
+	if e, ok := errs[0].(*EPackageNotUsed); ok && len(errs) == 1 {
+		require.Equal(t, pluginPkg, e.pkgName)
+		require.Len(t, e.provs, 2)
+		require.Equal(t, 1, e.provs[0].line)
+		require.Equal(t, 2, e.provs[1].line)
+		require.Equal(t, []string{godifPkg}, e.consumers)
+		require.Contains(t, e.Error(), "required by packages: "+godifPkg)
+	} else {
+		t.Fatal(errs)
+	}
+}
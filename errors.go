@@ -9,8 +9,13 @@ package godif
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/untillpro/gochips/errs"
 )
 
 // EMultipleStorageImplementations occurs if there are more than one implementations provided for slice or map
@@ -46,6 +51,37 @@ type EIncompatibleTypesFunc struct {
 	prov *srcPkgElem
 }
 
+// EIncompatibleTypesDecorator error occurs if decorator is not func(next T) T of its func target T
+type EIncompatibleTypesDecorator struct {
+	reqType reflect.Type
+	prov    *srcPkgElem
+}
+
+// EIncompatibleTypesStackedLayer error occurs if stacked layer is not func(prev T) T of its func target T
+type EIncompatibleTypesStackedLayer struct {
+	reqType reflect.Type
+	prov    *srcPkgElem
+}
+
+// EIncompatibleTypesLoader error occurs if loader is not func(T) error of its map or slice target T
+type EIncompatibleTypesLoader struct {
+	reqType reflect.Type
+	prov    *srcPkgElem
+}
+
+// EInterfaceNotImplemented error occurs if implementation provided for interface target does not implement it
+type EInterfaceNotImplemented struct {
+	req     *srcElem
+	prov    *srcPkgElem
+	missing []string
+}
+
+// EIncompatibleTypesPointer error occurs if type of a requirement (pointer, e.g. to struct) is incompatible to provided implementation
+type EIncompatibleTypesPointer struct {
+	req  *srcElem
+	prov *srcPkgElem
+}
+
 // EIncompatibleTypesStorageValue error occurs if type of an array or slice element or value of map is incompatible to provided implementation
 type EIncompatibleTypesStorageValue struct {
 	reqType reflect.Type
@@ -65,8 +101,11 @@ type EIncompatibleTypesStorageImpl struct {
 }
 
 // EPackageNotUsed s.e.
+// consumers are packages which require targets of the same types as provs, e.g. they get implementations from other packages
 type EPackageNotUsed struct {
-	pkgName string
+	pkgName   string
+	provs     []*srcPkgElem
+	consumers []string
 }
 
 // EMultipleValues error occurs if more than one value is provided per one key by ProvideMapValue() call
@@ -84,6 +123,116 @@ type EProvisionForNonAssignable struct {
 	provisionPlace *src
 }
 
+// EParentNotResolved occurs on ResolveAll() call of a child container if its parent is not resolved yet
+type EParentNotResolved struct {
+}
+
+// ESiblingResolved occurs on ResolveAll() call of a child container if another child of its parent is resolved and not reset
+// Children write to the same vars, so only one child of the parent can be resolved at a time
+type ESiblingResolved struct {
+	resolvePlace *src
+}
+
+// EReentrantCall occurs if constructor or loader calls the container which is being resolved, e.g. IsProvided() or MustGet()
+type EReentrantCall struct {
+	prov *srcPkgElem
+}
+
+// ENamedImplementationNotProvided occurs if implementation with the selected name is not provided
+type ENamedImplementationNotProvided struct {
+	sel    *selection
+	target interface{}
+}
+
+// EOverrideWithoutBase occurs if ProvideOverride() is called for a target which has no regular provision
+type EOverrideWithoutBase struct {
+	prov *srcPkgElem
+}
+
+// EMultipleOverrides occurs if ProvideOverride() is called more than once for one target
+type EMultipleOverrides struct {
+	provs []*srcPkgElem
+}
+
+// EInvalidConstructor occurs if constructor is not a func which returns implementation and optionally error or its target is not func, interface or pointer
+type EInvalidConstructor struct {
+	prov *srcPkgElem
+}
+
+// EConstructorDependencyNotResolved occurs if there is no or more than one provided target of constructor parameter type
+type EConstructorDependencyNotResolved struct {
+	prov       *srcPkgElem
+	paramType  reflect.Type
+	candidates []*srcPkgElem
+}
+
+// ECyclicDependency occurs if constructors depend on each other
+type ECyclicDependency struct {
+	steps []*srcPkgElem
+}
+
+// EConstructorFailed occurs if constructor returns error
+type EConstructorFailed struct {
+	prov *srcPkgElem
+	err  error
+}
+
+// EInvalidLazyProvision occurs if lazy factory is not a func without parameters which returns func (T for *Lazy[T] target) and optionally error or its target is neither func nor *Lazy[T]
+type EInvalidLazyProvision struct {
+	prov *srcPkgElem
+}
+
+// ELazyFailed is the panic value of lazy implementation if its factory returns error or panics
+type ELazyFailed struct {
+	prov *srcPkgElem
+	err  error
+}
+
+// ECyclicElementOrder occurs if Before and After of slice elements contradict each other
+type ECyclicElementOrder struct {
+	elems []*srcElem
+}
+
+// EMultipleNamedElements occurs if slice elements have the same ElementOrder.Name
+type EMultipleNamedElements struct {
+	name  string
+	elems []*srcElem
+}
+
+// EAlreadyInjected occurs if provision registered after resolve would replace or decorate injected implementation
+type EAlreadyInjected struct {
+	prov     *srcPkgElem
+	injected *srcPkgElem
+}
+
+// EUnsupportedBindingType occurs if target of ProvideFromEnv() or ProvideFromFlag() can't be converted from string
+type EUnsupportedBindingType struct {
+	binding *binding
+}
+
+// EBoundValueNotProvided occurs if required target is bound but neither environment variable nor flag has value
+type EBoundValueNotProvided struct {
+	bindings []*binding
+}
+
+// EBoundValueConversionFailed occurs if value of environment variable or flag can't be converted to the target type
+type EBoundValueConversionFailed struct {
+	binding *binding
+	value   string
+	err     error
+}
+
+// EFlagNotDefined occurs if flag of ProvideFromFlag() is not defined in the flag set
+type EFlagNotDefined struct {
+	binding *binding
+}
+
+// EInvalidSeverity occurs if Options change severity of the diagnostic which prevents injection
+type EInvalidSeverity struct {
+	code     string
+	severity Severity
+}
+
 func (e *EMultipleStorageImplementations) Error() string {
 	var buffer bytes.Buffer
 	for _, impl := range e.provs {
@@ -134,11 +283,44 @@ func (e *EIncompatibleTypesStorageImpl) Error() string {
 
 func (e *EIncompatibleTypesFunc) Error() string {
 	return fmt.Sprintf("Incompatible types: %s required at %s:%d, %s provided at %s:%d", reflect.TypeOf(e.req.elem), e.req.file, e.req.line,
+		e.prov.implType(), e.prov.file, e.prov.line)
+}
+
+func (e *EIncompatibleTypesDecorator) Error() string {
+	return fmt.Sprintf("Incompatible types: target is %s but decorator %s provided at %s:%d, decorator must be func(next %[1]s) %[1]s", e.reqType,
 		reflect.TypeOf(e.prov.elem), e.prov.file, e.prov.line)
 }
 
+func (e *EIncompatibleTypesStackedLayer) Error() string {
+	return fmt.Sprintf("Incompatible types: target is %s but stacked layer %s provided at %s:%d, layer must be func(prev %[1]s) %[1]s", e.reqType,
+		reflect.TypeOf(e.prov.elem), e.prov.file, e.prov.line)
+}
+
+func (e *EIncompatibleTypesLoader) Error() string {
+	return fmt.Sprintf("Incompatible types: target is %s but loader %s provided at %s:%d, target must be map or slice and loader must be func(%[1]s) error", e.reqType,
+		reflect.TypeOf(e.prov.elem), e.prov.file, e.prov.line)
+}
+
+func (e *EInterfaceNotImplemented) Error() string {
+	return fmt.Sprintf("%s required at %s:%d is not implemented by %s provided at %s:%d, missing methods: %s", reflect.TypeOf(e.req.elem).Elem(),
+		e.req.file, e.req.line, e.prov.implType(), e.prov.file, e.prov.line, strings.Join(e.missing, ", "))
+}
+
+func (e *EIncompatibleTypesPointer) Error() string {
+	return fmt.Sprintf("Incompatible types: %s required at %s:%d, %s provided at %s:%d", reflect.TypeOf(e.req.elem).Elem(), e.req.file, e.req.line,
+		e.prov.implType(), e.prov.file, e.prov.line)
+}
+
 func (e *EPackageNotUsed) Error() string {
-	return fmt.Sprintf("Have provisions from package %s but nothing is required from this package", e.pkgName)
+	var buffer bytes.Buffer
+	for _, prov := range e.provs {
+		buffer.WriteString(fmt.Sprintf("\t%s at %s:%d\r\n", prov.implType(), prov.file, prov.line))
+	}
+	consumers := "No package requires targets of these types"
+	if len(e.consumers) > 0 {
+		consumers = "Targets of these types are required by packages: " + strings.Join(e.consumers, ", ")
+	}
+	return fmt.Sprintf("Have provisions from package %s but nothing is required from this package, provisions at:\r\n%s%s", e.pkgName, buffer.String(), consumers)
 }
 
 func (e *EMultipleValues) Error() string {
@@ -157,3 +339,254 @@ func (e *EAlreadyResolved) Error() string {
 func (e *EProvisionForNonAssignable) Error() string {
 	return fmt.Sprintf("Non-assignable var is provided at %s:%d. Use pointers to target on Require() and Provide()", e.provisionPlace.file, e.provisionPlace.line)
 }
+
+func (e *EParentNotResolved) Error() string {
+	return "Parent container is not resolved. Call ResolveAll() for parent before child"
+}
+
+func (e *EReentrantCall) Error() string {
+	return fmt.Sprintf("Constructor or loader provided at %s:%d calls the container which is being resolved, use constructor parameters instead",
+		e.prov.file, e.prov.line)
+}
+
+func (e *ESiblingResolved) Error() string {
+	return fmt.Sprintf("Another child of the parent container is resolved at %s:%d. Call Reset() for it before resolving this child",
+		e.resolvePlace.file, e.resolvePlace.line)
+}
+
+func (e *ENamedImplementationNotProvided) Error() string {
+	if len(e.sel.envVar) > 0 {
+		return fmt.Sprintf("Implementation of %T named %q (selected by %s environment variable at %s:%d) is not provided", e.target,
+			e.sel.selectedName(), e.sel.envVar, e.sel.file, e.sel.line)
+	}
+	return fmt.Sprintf("Implementation of %T named %q selected at %s:%d is not provided", e.target, e.sel.name, e.sel.file, e.sel.line)
+}
+
+func (e *EOverrideWithoutBase) Error() string {
+	return fmt.Sprintf("Override of %T provided at %s:%d has nothing to override. Use Provide() instead", e.prov.elem, e.prov.file, e.prov.line)
+}
+
+func (e *EMultipleOverrides) Error() string {
+	var buffer bytes.Buffer
+	for _, impl := range e.provs {
+		buffer.WriteString(fmt.Sprintf("\t%s:%d\r\n", impl.file, impl.line))
+	}
+
+	return fmt.Sprintf("Multiple overrides of one target at:\r\n%s", buffer.String())
+}
+
+func (e *EInvalidConstructor) Error() string {
+	return fmt.Sprintf("Invalid constructor %T provided at %s:%d. Constructor must return implementation and optionally error, target must be func, interface or pointer",
+		e.prov.elem, e.prov.file, e.prov.line)
+}
+
+func (e *EConstructorDependencyNotResolved) Error() string {
+	if len(e.candidates) == 0 {
+		return fmt.Sprintf("Constructor provided at %s:%d depends on %s which is not provided", e.prov.file, e.prov.line, e.paramType)
+	}
+	var buffer bytes.Buffer
+	for _, impl := range e.candidates {
+		buffer.WriteString(fmt.Sprintf("\t%s:%d\r\n", impl.file, impl.line))
+	}
+	return fmt.Sprintf("Constructor provided at %s:%d depends on %s which is provided for multiple targets at:\r\n%s", e.prov.file, e.prov.line,
+		e.paramType, buffer.String())
+}
+
+func (e *ECyclicDependency) Error() string {
+	var buffer bytes.Buffer
+	for _, step := range e.steps {
+		buffer.WriteString(fmt.Sprintf("\t%s at %s:%d\r\n", step.implType(), step.file, step.line))
+	}
+	return fmt.Sprintf("Cyclic dependency between constructors:\r\n%s", buffer.String())
+}
+
+func (e *EConstructorFailed) Error() string {
+	return fmt.Sprintf("Constructor provided at %s:%d failed: %v", e.prov.file, e.prov.line, e.err)
+}
+
+func (e *EInvalidLazyProvision) Error() string {
+	return fmt.Sprintf("Invalid lazy factory %T provided at %s:%d. Factory must have no parameters and return func (T for *Lazy[T] target) and optionally error, target must be func or *Lazy[T]",
+		e.prov.elem, e.prov.file, e.prov.line)
+}
+
+func (e *ELazyFailed) Error() string {
+	return fmt.Sprintf("Lazy implementation provided at %s:%d failed: %v", e.prov.file, e.prov.line, e.err)
+}
+
+func (e *ECyclicElementOrder) Error() string {
+	var buffer bytes.Buffer
+	for _, elem := range e.elems {
+		buffer.WriteString(fmt.Sprintf("\t%s:%d\r\n", elem.file, elem.line))
+	}
+	return fmt.Sprintf("Cyclic order of slice elements, each one must precede the next one and the last one must precede the first one:\r\n%s", buffer.String())
+}
+
+func (e *EMultipleNamedElements) Error() string {
+	var buffer bytes.Buffer
+	for _, elem := range e.elems {
+		buffer.WriteString(fmt.Sprintf("\t%s:%d\r\n", elem.file, elem.line))
+	}
+	return fmt.Sprintf("Multiple slice elements named %q at:\r\n%s", e.name, buffer.String())
+}
+
+func (e *EAlreadyInjected) Error() string {
+	return fmt.Sprintf("%T provided at %s:%d can't replace or decorate implementation injected from %s:%d", e.prov.elem, e.prov.file, e.prov.line,
+		e.injected.file, e.injected.line)
+}
+
+func (e *EUnsupportedBindingType) Error() string {
+	return fmt.Sprintf("%s bound to %s at %s:%d can't be converted from string", reflect.TypeOf(e.binding.elem).Elem(), e.binding.source(),
+		e.binding.file, e.binding.line)
+}
+
+func (e *EBoundValueNotProvided) Error() string {
+	var sources []string
+	for _, b := range e.bindings {
+		sources = append(sources, fmt.Sprintf("%s bound at %s:%d", b.source(), b.file, b.line))
+	}
+	return fmt.Sprintf("Value of %s is not provided: %s", reflect.TypeOf(e.bindings[0].elem).Elem(), strings.Join(sources, ", "))
+}
+
+func (e *EBoundValueConversionFailed) Error() string {
+	return fmt.Sprintf("%q of %s bound at %s:%d can't be converted to %s: %s", e.value, e.binding.source(), e.binding.file, e.binding.line,
+		reflect.TypeOf(e.binding.elem).Elem(), e.err)
+}
+
+func (e *EFlagNotDefined) Error() string {
+	return fmt.Sprintf("%s bound at %s:%d is not defined in the flag set", e.binding.source(), e.binding.file, e.binding.line)
+}
+
+func (e *EInvalidSeverity) Error() string {
+	return fmt.Sprintf("Severity of %s can't be changed, only EPackageNotUsed, EImplementationProvidedForNonNil, EOverrideWithoutBase, ECyclicElementOrder and EMultipleNamedElements are configurable",
+		e.code)
+}
+
+// ErrorCode returns stable code of the error returned by ResolveAll(), which is the name of its type, e.g. "EImplementationNotProvided"
+// Wrapped godif error gives its code, other errors give name of their type, nil gives ""
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if t := reflect.TypeOf(e); t.Kind() == reflect.Ptr && t.Elem().PkgPath() == errorsPkgPath {
+			return t.Elem().Name()
+		}
+	}
+	t := reflect.TypeOf(err)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+var errorsPkgPath = reflect.TypeOf(EImplementationNotProvided{}).PkgPath()
+
+// errorLocation returns the main source location of the error, nil if the error has no location
+func errorLocation(err error) *src {
+	switch e := err.(type) {
+	case *EMultipleStorageImplementations:
+		return e.provs[0].src
+	case *EMultipleFuncImplementations:
+		return e.req.src
+	case *EImplementationNotProvided:
+		return e.req.src
+	case *EImplementationProvidedForNonNil:
+		return e.prov.src
+	case *ENonAssignableRequirement:
+		return e.req
+	case *EIncompatibleTypesFunc:
+		return e.req.src
+	case *EIncompatibleTypesDecorator:
+		return e.prov.src
+	case *EIncompatibleTypesLoader:
+		return e.prov.src
+	case *EIncompatibleTypesStackedLayer:
+		return e.prov.src
+	case *EInterfaceNotImplemented:
+		return e.req.src
+	case *EIncompatibleTypesPointer:
+		return e.req.src
+	case *EIncompatibleTypesStorageValue:
+		return e.prov.src
+	case *EIncompatibleTypesStorageKey:
+		return e.prov.src
+	case *EIncompatibleTypesStorageImpl:
+		return e.prov.src
+	case *EMultipleValues:
+		return e.provs[0].src
+	case *EAlreadyResolved:
+		return e.resolvePlace
+	case *ESiblingResolved:
+		return e.resolvePlace
+	case *EReentrantCall:
+		return e.prov.src
+	case *EProvisionForNonAssignable:
+		return e.provisionPlace
+	case *ENamedImplementationNotProvided:
+		return e.sel.src
+	case *EOverrideWithoutBase:
+		return e.prov.src
+	case *EMultipleOverrides:
+		return e.provs[0].src
+	case *EInvalidConstructor:
+		return e.prov.src
+	case *EConstructorDependencyNotResolved:
+		return e.prov.src
+	case *ECyclicDependency:
+		return e.steps[0].src
+	case *EConstructorFailed:
+		return e.prov.src
+	case *EInvalidLazyProvision:
+		return e.prov.src
+	case *ELazyFailed:
+		return e.prov.src
+	case *ECyclicElementOrder:
+		return e.elems[0].src
+	case *EMultipleNamedElements:
+		return e.elems[0].src
+	case *EAlreadyInjected:
+		return e.prov.src
+	case *EUnsupportedBindingType:
+		return e.binding.src
+	case *EBoundValueNotProvided:
+		return e.bindings[0].src
+	case *EBoundValueConversionFailed:
+		return e.binding.src
+	case *EFlagNotDefined:
+		return e.binding.src
+	}
+	return nil
+}
+
+// sortErrors sorts errors by file and line, then by code and message, errors without location go last
+func sortErrors(errs errs.Errors) errs.Errors {
+	sort.SliceStable(errs, func(i, j int) bool {
+		li, lj := errorLocation(errs[i]), errorLocation(errs[j])
+		switch {
+		case li == nil && lj != nil:
+			return false
+		case li != nil && lj == nil:
+			return true
+		case li != nil && lj != nil && li.file != lj.file:
+			return li.file < lj.file
+		case li != nil && lj != nil && li.line != lj.line:
+			return li.line < lj.line
+		}
+		if ci, cj := ErrorCode(errs[i]), ErrorCode(errs[j]); ci != cj {
+			return ci < cj
+		}
+		return errs[i].Error() < errs[j].Error()
+	})
+	return errs
+}
+
+// sortSrcs sorts provisions by file and line
+func sortSrcs(provs []*srcPkgElem) []*srcPkgElem {
+	sort.SliceStable(provs, func(i, j int) bool {
+		if provs[i].file != provs[j].file {
+			return provs[i].file < provs[j].file
+		}
+		return provs[i].line < provs[j].line
+	})
+	return provs
+}
@@ -1,9 +1,14 @@
 module github.com/untillpro/godif
 
 require (
-	github.com/stretchr/objx v0.2.0 // indirect
 	github.com/stretchr/testify v1.3.0
 	github.com/untillpro/gochips v1.12.1-0.20191205115612-9cd10d0ac2b3
 )
 
-go 1.13
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/stretchr/objx v0.2.0 // indirect
+)
+
+go 1.18
@@ -8,9 +8,13 @@
 package godif
 
 import (
+	"fmt"
 	"reflect"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/untillpro/gochips/errs"
 )
@@ -23,343 +27,189 @@ type src struct {
 type srcElem struct {
 	*src
 	elem interface{}
+	// pkg is the package which registers the element
+	pkg string
 }
 
+type provisionKind int
+
+const (
+	provisionRegular provisionKind = iota
+	provisionOverride
+	provisionDefault
+	provisionFallback
+)
+
 type srcPkgElem struct {
 	*srcElem
-	pkg string
+	name        string
+	kind        provisionKind
+	constructor bool
+	lazy        bool
+	// holder is the type of *Lazy[T] target of lazy provision, nil for func target
+	holder reflect.Type
+	// profile is given by ProvideIn()
+	profile string
+	// seq is the provision order across all containers
+	seq int64
 }
 
-var (
-	required        map[interface{}]*srcElem
-	provided        map[interface{}][]*srcPkgElem
-	keyValues       map[interface{}]map[interface{}][]*srcElem
-	sliceElements   map[interface{}][]*srcElem
-	resolveSrc      *src
-	unhashableProvs []*src
-	unhashableReqs  []*src
-)
+// Package-level functions operate on the default container
+var defaultContainer = NewContainer()
 
-func init() {
-	Reset()
+func newSrcElem(file string, line int, pkg string, elem interface{}) *srcElem {
+	return &srcElem{&src{file, line}, elem, pkg}
 }
 
-func newSrcElem(file string, line int, elem interface{}) *srcElem {
-	return &srcElem{&src{file, line}, elem}
+func newSrcPkgElem(file string, line int, pkg string, elem interface{}) *srcPkgElem {
+	return &srcPkgElem{srcElem: newSrcElem(file, line, pkg, elem), seq: atomic.AddInt64(&provisionSeq, 1)}
 }
 
-func newSrcPkgElem(file string, line int, pkg string, elem interface{}) *srcPkgElem {
-	return &srcPkgElem{newSrcElem(file, line, elem), pkg}
+var provisionSeq int64
+
+// targetsInProvisionOrder returns targets sorted by their first provisions
+func targetsInProvisionOrder(provided map[interface{}][]*srcPkgElem) []interface{} {
+	targets := make([]interface{}, 0, len(provided))
+	for target := range provided {
+		targets = append(targets, target)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return provided[targets[i]][0].seq < provided[targets[j]][0].seq
+	})
+	return targets
 }
 
-// Reset clears all assignations
-func Reset() {
-	for _, r := range required {
-		v := reflect.ValueOf(r.elem)
-		if v.Kind() == reflect.Ptr {
-			v = v.Elem()
-			if v.CanSet() {
-				v.Set(reflect.Zero(v.Type()))
-			}
+// callerSrcPkgElem creates srcPkgElem located at the caller which is skip frames up
+func callerSrcPkgElem(skip int, elem interface{}) *srcPkgElem {
+	pkgName, file, line := caller(skip)
+	return newSrcPkgElem(file, line, pkgName, elem)
+}
+
+// Functions of typed package wrap ones of godif, so their frames are skipped when caller is located
+const typedPkgPrefix = "github.com/untillpro/godif/typed."
+
+// caller returns package, file and line of the caller which is skip frames up, like runtime.Caller(skip) does
+func caller(skip int) (pkgName string, file string, line int) {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, typedPkgPrefix) || !more {
+			return funcPackage(frame.Function), frame.File, frame.Line
 		}
 	}
-	for p := range provided {
-		if _, ok := required[p]; !ok {
-			if _, ok := keyValues[p]; !ok {
-				if _, ok := sliceElements[p]; !ok {
-					continue
-				}
-			}
-		}
-		v := reflect.ValueOf(p)
-		if v.Kind() == reflect.Ptr {
-			v = v.Elem()
-			if v.CanSet() {
-				v.Set(reflect.Zero(v.Type()))
-			}
-		}
+}
+
+// funcPackage returns package of the function, e.g. "github.com/a/b" for "github.com/a/b.(*T).M.func1" or "github.com/a/b.F[...]"
+// Dots of the last path element are escaped in function names, e.g. "gopkg.in/yaml%2ev2.Unmarshal"
+func funcPackage(name string) string {
+	if i := strings.Index(name, "["); i >= 0 {
+		name = name[:i]
+	}
+	slash := strings.LastIndex(name, "/")
+	if dot := strings.Index(name[slash+1:], "."); dot >= 0 {
+		name = name[:slash+1+dot]
 	}
-	resolveSrc = nil
-	unhashableProvs = []*src{}
-	unhashableReqs = []*src{}
-	required = map[interface{}]*srcElem{}
-	provided = make(map[interface{}][]*srcPkgElem)
-	keyValues = make(map[interface{}]map[interface{}][]*srcElem)
-	sliceElements = make(map[interface{}][]*srcElem)
+	return strings.ReplaceAll(name, "%2e", ".")
+}
+
+// goroutineID returns id of the current goroutine, it is taken from the stack header, e.g. "goroutine 7 [running]:"
+func goroutineID() int64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	fields := strings.Fields(strings.TrimPrefix(string(buf), "goroutine "))
+	if len(fields) == 0 {
+		return 0
+	}
+	id, _ := strconv.ParseInt(fields[0], 10, 64)
+	return id
+}
+
+// Reset clears all assignations
+func Reset() {
+	defaultContainer.Reset()
 }
 
 // ProvideSliceElement s.e.
 func ProvideSliceElement(pointerToSlice interface{}, element interface{}) {
-	_, file, line, _ := runtime.Caller(1)
-	srcElement := newSrcElem(file, line, element)
-	if isHashable(pointerToSlice) {
-		sliceElements[pointerToSlice] = append(sliceElements[pointerToSlice], srcElement)
-	} else {
-		unhashableProvs = append(unhashableProvs, srcElement.src)
-	}
+	defaultContainer.provideSliceElement(pointerToSlice, element)
 }
 
 // ProvideKeyValue s.e.
 func ProvideKeyValue(pointerToMap interface{}, key interface{}, value interface{}) {
-	_, file, line, _ := runtime.Caller(1)
-	srcElement := newSrcElem(file, line, value)
-	if isHashable(pointerToMap) {
-		if keyValues[pointerToMap] == nil {
-			keyValues[pointerToMap] = make(map[interface{}][]*srcElem)
-		}
-		keyValues[pointerToMap][key] = append(keyValues[pointerToMap][key], srcElement)
-	} else {
-		unhashableProvs = append(unhashableProvs, srcElement.src)
-	}
+	defaultContainer.provideKeyValue(pointerToMap, key, value)
 }
 
 // Provide registers implementation of ref type
 func Provide(ref interface{}, funcImplementation interface{}) {
-	pc, file, line, _ := runtime.Caller(1)
-	nameFull := runtime.FuncForPC(pc).Name()
-	pkgName := nameFull[:strings.LastIndex(nameFull, ".")]
-	srcElem := newSrcPkgElem(file, line, pkgName, funcImplementation)
-	if isHashable(ref) {
-		provided[ref] = append(provided[ref], srcElem)
-	} else {
-		unhashableProvs = append(unhashableProvs, srcElem.src)
-	}
+	defaultContainer.provide(ref, funcImplementation)
 }
 
 // Require registers dep
 func Require(toInject interface{}) {
-	_, file, line, _ := runtime.Caller(1)
-	if isHashable(toInject) {
-		required[toInject] = newSrcElem(file, line, toInject)
-	} else {
-		unhashableReqs = append(unhashableReqs, &src{file, line})
-	}
+	defaultContainer.require(toInject)
 }
 
 // ResolveAll all deps
 func ResolveAll() errs.Errors {
-	if errs := validate(); errs != nil {
-		return errs
-	}
-
-	for target, provVar := range provided {
-		// implementation and key-value provided -> consider implicitly required. Will initialize.
-		if _, ok := required[target]; !ok {
-			if _, ok := keyValues[target]; !ok {
-				if _, ok := sliceElements[target]; !ok {
-					continue
-				}
-			}
-		}
-		if targetValue := reflect.ValueOf(target).Elem(); targetValue.IsNil() {
-			targetValue.Set(reflect.ValueOf(provVar[0].elem))
-		}
-	}
-
-	for targetMap, kvToAppend := range keyValues {
-		targetMapType := reflect.TypeOf(targetMap).Elem()
-		tragetMapValueType := targetMapType.Elem()
-		tragetMapValueKind := tragetMapValueType.Kind()
-		targetMapValue := reflect.ValueOf(targetMap).Elem()
-		for k, v := range kvToAppend {
-			keyValue := reflect.ValueOf(k)
-			var toAppendValue reflect.Value
-			if isSlice(tragetMapValueKind) {
-				existingSlice := targetMapValue.MapIndex(keyValue)
-				newSlice := reflect.New(reflect.SliceOf(tragetMapValueType.Elem())).Elem()
-				if existingSlice.IsValid() {
-					for i := 0; i < existingSlice.Len(); i++ {
-						existingElement := existingSlice.Index(i)
-						newSlice.Set(reflect.Append(newSlice, existingElement))
-					}
-				}
-				for _, elementToAppend := range v {
-					elementToAppendValue := reflect.ValueOf(elementToAppend.elem)
-					elementToAppendKind := elementToAppendValue.Kind()
-					if isSlice(elementToAppendKind) {
-						for i := 0; i < elementToAppendValue.Len(); i++ {
-							newSlice.Set(reflect.Append(newSlice, elementToAppendValue.Index(i)))
-						}
-					} else {
-						newSlice.Set(reflect.Append(newSlice, elementToAppendValue))
-					}
-				}
-				toAppendValue = newSlice
-			} else {
-				toAppendValue = reflect.ValueOf(v[0].elem)
-			}
-			targetMapValue.SetMapIndex(keyValue, toAppendValue)
-		}
-	}
-
-	for targetSlice, elementsToAppend := range sliceElements {
-		targateSliceValue := reflect.ValueOf(targetSlice).Elem()
-		for _, elementToAppend := range elementsToAppend {
-			elementValue := reflect.ValueOf(elementToAppend.elem)
-			elementKind := elementValue.Kind()
-			if isSlice(elementKind) {
-				for i := 0; i < elementValue.Len(); i++ {
-					targateSliceValue.Set(reflect.Append(targateSliceValue, elementValue.Index(i)))
-				}
-			} else {
-				targateSliceValue.Set(reflect.Append(targateSliceValue, elementValue))
-			}
-		}
-	}
-
-	_, file, line, _ := runtime.Caller(1)
-	resolveSrc = &src{file, line}
+	return defaultContainer.resolveAll()
+}
 
-	return nil
+// Validate runs all checks of ResolveAll() without calling constructors and assigning targets
+func Validate() errs.Errors {
+	return defaultContainer.Validate()
 }
 
 func isSlice(kind reflect.Kind) bool {
 	return kind == reflect.Array || kind == reflect.Slice
 }
 
-func isHashable(intf interface{}) bool {
-	k := reflect.TypeOf(intf).Kind()
-	return k < reflect.Array || k == reflect.Ptr || k == reflect.UnsafePointer
-}
-
-func validate() (errs errs.Errors) {
-	if resolveSrc != nil {
-		return errs.AddE(&EAlreadyResolved{resolveSrc})
-	}
-
-	requiredPackages := make(map[string]bool)
-
-	if len(unhashableProvs) > 0 {
-		for _, unhashableProvsSrc := range unhashableProvs {
-			errs.AddE(&EProvisionForNonAssignable{unhashableProvsSrc})
-		}
-		return errs
-	}
-
-	if len(unhashableReqs) > 0 {
-		for _, unhashableReqSrc := range unhashableReqs {
-			errs.AddE(&ENonAssignableRequirement{unhashableReqSrc})
-		}
-		return errs
-	}
-
-	for _, req := range required {
-		impls := provided[req.elem]
-
-		if nil == impls {
-			errs.AddE(&EImplementationNotProvided{req, nil})
-		}
-
-		if len(impls) > 1 {
-			errs.AddE(&EMultipleFuncImplementations{req, impls})
-		}
-
-		reqType := reflect.TypeOf(req.elem).Elem()
-
-		for _, impl := range impls {
-			requiredPackages[impl.pkg] = true
-			implType := reflect.TypeOf(impl.elem)
-			if !implType.AssignableTo(reqType) {
-				errs.AddE(&EIncompatibleTypesFunc{req, impl})
-			}
-		}
-	}
-
-	for targetMap, kvToAppend := range keyValues {
-		targetMapType := reflect.TypeOf(targetMap).Elem()
-		targetMapValue := reflect.ValueOf(targetMap).Elem()
-		targetMapKeyType := targetMapType.Key()
-		impl := provided[targetMap]
-		if targetMapValue.IsNil() {
-			keys := reflect.ValueOf(kvToAppend).MapKeys()
-			if impl == nil {
-				errs.AddE(&EImplementationNotProvided{kvToAppend[keys[0].Interface()][0], targetMap})
-				continue
-			}
-		} else {
-			if impl != nil {
-				errs.AddE(&EImplementationProvidedForNonNil{impl[0]})
-				continue
-			}
-		}
-		targetMapValueType := targetMapType.Elem()
-		targetMapValueKind := targetMapValueType.Kind()
-		for k, v := range kvToAppend {
-			if isSlice(targetMapValueKind) {
-				reqMapValueSliceElementType := targetMapValueType.Elem()
-				for _, provElement := range v {
-					provType := reflect.TypeOf(provElement.elem)
-					provKind := provType.Kind()
-					if isSlice(provKind) {
-						provType = provType.Elem()
-					}
-					if !provType.AssignableTo(reqMapValueSliceElementType) {
-						errs.AddE(&EIncompatibleTypesStorageValue{targetMapType, provElement})
-					}
-				}
-			} else {
-				if len(v) > 1 {
-					errs.AddE(&EMultipleValues{v})
-				} else {
-					vType := reflect.TypeOf(v[0].elem)
-					if !vType.AssignableTo(targetMapValueType) {
-						errs.AddE(&EIncompatibleTypesStorageValue{targetMapType, v[0]})
-					}
-					kType := reflect.TypeOf(k)
-					if !kType.AssignableTo(targetMapKeyType) {
-						errs.AddE(&EIncompatibleTypesStorageKey{targetMapType, newSrcElem(v[0].file, v[0].line, k)})
-					}
-				}
-			}
-		}
-	}
-
-	for targetSlice, elementsToAppend := range sliceElements {
-		targetSliceType := reflect.TypeOf(targetSlice).Elem()
-		for _, v := range elementsToAppend {
-			vType := reflect.TypeOf(v.elem)
-			vKind := vType.Kind()
-			if isSlice(vKind) {
-				vType = vType.Elem()
-			}
-			if !vType.AssignableTo(targetSliceType.Elem()) {
-				errs.AddE(&EIncompatibleTypesStorageValue{targetSliceType, v})
-			}
-		}
+// isEmpty returns true if the target value is nil or, for kinds which can't be nil, e.g. int or struct of ProvideType(), zero
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return v.IsNil()
 	}
+	return v.IsZero()
+}
 
-	pkgNotUsedErrorsAppended := make(map[string]bool)
+// isService returns true for targets which get exactly one implementation from a required package: funcs, interfaces and pointers
+func isService(kind reflect.Kind) bool {
+	return kind == reflect.Func || kind == reflect.Interface || kind == reflect.Ptr
+}
 
-	for provVar, provSrcs := range provided {
-		provKind := reflect.TypeOf(provVar).Elem().Kind()
-		if provKind != reflect.Func && len(provSrcs) > 1 {
-			errs.AddE(&EMultipleStorageImplementations{provSrcs})
+// missingMethods returns names of intf methods which implType does not have or has with different signatures
+func missingMethods(intf reflect.Type, implType reflect.Type) []string {
+	var res []string
+	for i := 0; i < intf.NumMethod(); i++ {
+		method := intf.Method(i)
+		implMethod, ok := implType.MethodByName(method.Name)
+		if !ok {
+			res = append(res, method.Name)
 			continue
 		}
-		provType := reflect.TypeOf(provSrcs[0].elem)
-		targetType := reflect.TypeOf(provVar).Elem()
-		targetKind := targetType.Kind()
-
-		switch targetKind {
-		case reflect.Func:
-			if _, required := requiredPackages[provSrcs[0].pkg]; !required {
-				if !pkgNotUsedErrorsAppended[provSrcs[0].pkg] {
-					errs.AddE(&EPackageNotUsed{provSrcs[0].pkg})
-					pkgNotUsedErrorsAppended[provSrcs[0].pkg] = true
-				}
-			}
-		case reflect.Array, reflect.Slice, reflect.Map:
-			if isSlice(targetKind) {
-				targetSliceValue := reflect.ValueOf(provVar).Elem()
-				if !targetSliceValue.IsNil() {
-					errs.AddE(&EImplementationProvidedForNonNil{provSrcs[0]})
-				}
+		implMethodType := implMethod.Type
+		if implType.Kind() != reflect.Interface {
+			// skip receiver
+			in := make([]reflect.Type, implMethodType.NumIn()-1)
+			for j := range in {
+				in[j] = implMethodType.In(j + 1)
 			}
-			if !provType.AssignableTo(targetType) {
-				errs.AddE(&EIncompatibleTypesStorageImpl{targetType, provSrcs[0].srcElem})
+			out := make([]reflect.Type, implMethodType.NumOut())
+			for j := range out {
+				out[j] = implMethodType.Out(j)
 			}
+			implMethodType = reflect.FuncOf(in, out, implMethodType.IsVariadic())
+		}
+		if implMethodType != method.Type {
+			res = append(res, fmt.Sprintf("%s (has %s, wants %s)", method.Name, implMethodType, method.Type))
 		}
 	}
+	return res
+}
 
-	return errs
+func isHashable(intf interface{}) bool {
+	k := reflect.TypeOf(intf).Kind()
+	return k < reflect.Array || k == reflect.Ptr || k == reflect.UnsafePointer
 }
@@ -9,6 +9,7 @@ package godif
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"runtime"
@@ -117,6 +118,71 @@ func TestMultipleErrorsOnResolve(t *testing.T) {
 	}
 }
 
+func TestErrorsAreSorted(t *testing.T) {
+	var prev string
+	for i := 0; i < 10; i++ {
+		Reset()
+		var injectedFunc1 func(x int, y int) int
+		var injectedFunc2 func(x float32) float32
+		var injectedFunc3 func(x int, y int) int
+		var myMap map[string]int
+
+		_, file, line, _ := runtime.Caller(0)
+		Require(&injectedFunc3)
+		Require(&injectedFunc2)
+		Require(&injectedFunc1)
+		ProvideKeyValue(&myMap, "key1", 1)
+		Provide(&injectedFunc1, f2)
+		Provide(&injectedFunc3, f)
+		Provide(&injectedFunc3, f3)
+
+		errs := ResolveAll()
+		require.Len(t, errs, 4, errs)
+		require.Equal(t, "EMultipleFuncImplementations", ErrorCode(errs[0]))
+		require.Equal(t, "EImplementationNotProvided", ErrorCode(errs[1]))
+		require.Equal(t, "EIncompatibleTypesFunc", ErrorCode(errs[2]))
+		require.Equal(t, "EImplementationNotProvided", ErrorCode(errs[3]))
+		for j, err := range errs {
+			require.Equal(t, file, errorLocation(err).file)
+			require.Equal(t, line+j+1, errorLocation(err).line)
+		}
+		if i > 0 {
+			require.Equal(t, prev, errs.Error())
+		}
+		prev = errs.Error()
+	}
+}
+
+type plainError string
+
+func (e plainError) Error() string {
+	return string(e)
+}
+
+func TestErrorCodeOfOtherErrors(t *testing.T) {
+	require.Equal(t, "", ErrorCode(nil))
+	require.Equal(t, "plainError", ErrorCode(plainError("plain")))
+	require.Equal(t, "errorString", ErrorCode(errors.New("plain")))
+	require.Equal(t, "EImplementationNotProvided", ErrorCode(fmt.Errorf("wrapped: %w", &EImplementationNotProvided{})))
+}
+
+func TestKeySliceMergedInProvisionOrder(t *testing.T) {
+	Reset()
+	var myMap map[string][]int
+	var expected []int
+
+	Provide(&myMap, map[string][]int{})
+	for i := 0; i < 20; i++ {
+		ProvideKeyValue(&myMap, fmt.Sprint("key", i%3), i)
+		if i%3 == 1 {
+			expected = append(expected, i)
+		}
+	}
+
+	require.Nil(t, ResolveAll())
+	require.Equal(t, expected, myMap["key1"])
+}
+
 func TestErrorOnNonAssignableVarOnProvideFunc(t *testing.T) {
 	Reset()
 	var injectedFunc func(x int, y int) int
@@ -313,6 +379,43 @@ func TestPackageNotUsedIfNothingRequired(t *testing.T) {
 	require.Nil(t, injected2)
 }
 
+func TestValidate(t *testing.T) {
+	Reset()
+	var injected1 func(x int, y int) int
+	var injected2 func(x float32) float32
+	var mySlice []string
+	calls := 0
+
+	Provide(&injected1, f)
+	ProvideConstructor(&injected2, func() func(x float32) float32 {
+		calls++
+		return f2
+	})
+	ProvideSliceElement(&mySlice, "str1")
+
+	// package usage is checked
+	errs := Validate()
+	if _, ok := errs[0].(*EPackageNotUsed); !ok || len(errs) != 1 {
+		t.Fatal(errs)
+	}
+
+	Require(&injected1)
+	Require(&injected2)
+	require.Nil(t, Validate())
+	require.Nil(t, injected1)
+	require.Nil(t, injected2)
+	require.Nil(t, mySlice)
+	require.Equal(t, 0, calls)
+
+	require.Nil(t, ResolveAll())
+	require.Equal(t, 5, injected1(3, 2))
+	require.Equal(t, 1, calls)
+	errs = Validate()
+	if _, ok := errs[0].(*EAlreadyResolved); !ok || len(errs) != 1 {
+		t.Fatal(errs)
+	}
+}
+
 func TestDataInject(t *testing.T) {
 	Reset()
 	var injected map[string]int
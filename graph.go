@@ -0,0 +1,221 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Graph node kinds
+const (
+	NodePackage = "package"
+	NodeTarget  = "target"
+)
+
+// Graph edge kinds
+const (
+	EdgeRequires = "requires"
+	EdgeProvides = "provides"
+	EdgeExtends  = "extends"
+)
+
+// GraphNode is a package or a target
+type GraphNode struct {
+	ID    string `json:"id"`
+	Kind  string `json:"kind"`
+	Label string `json:"label"`
+	// Unsatisfied is true for targets which are required but neither provided nor injected
+	Unsatisfied bool `json:"unsatisfied,omitempty"`
+}
+
+// GraphEdge goes from a package to a target
+type GraphEdge struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Kind        string `json:"kind"`
+	Unsatisfied bool   `json:"unsatisfied,omitempty"`
+}
+
+// Graph is the dependency graph of packages and targets
+// Source locations are not included, target ID is its type and owner package, so the graph does not change when code moves
+// Only targets of the same type owned by the same package are numbered in order of the snapshot lists
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// Graph builds the dependency graph: packages require and provide targets, ProvideKeyValue() and ProvideSliceElement() extend targets
+// Nodes and edges go in order of the snapshot lists: requirements, provisions, key values, slice elements
+func (s RegistrySnapshot) Graph() Graph {
+	var g Graph
+	nodes := make(map[string]bool)
+	edges := make(map[GraphEdge]bool)
+	targetIDs := make(map[interface{}]string)
+	typeCount := make(map[string]int)
+
+	provided := make(map[interface{}]bool)
+	for _, prov := range s.Provisions {
+		if prov.Kind != KindDecorator && prov.Kind != KindStacked {
+			provided[prov.Target] = true
+		}
+	}
+	unsatisfied := make(map[interface{}]bool)
+	for _, req := range s.Requirements {
+		if !req.Optional && !req.Injected && !provided[req.Target] {
+			unsatisfied[req.Target] = true
+		}
+	}
+
+	owners := targetOwners(s)
+
+	addNode := func(node GraphNode) {
+		if !nodes[node.ID] {
+			nodes[node.ID] = true
+			g.Nodes = append(g.Nodes, node)
+		}
+	}
+	pkgNode := func(pkg string) string {
+		id := "pkg:" + pkg
+		addNode(GraphNode{ID: id, Kind: NodePackage, Label: pkg})
+		return id
+	}
+	targetNode := func(target interface{}, label string) string {
+		id, ok := targetIDs[target]
+		if !ok {
+			// targets of the same type owned by the same package are numbered
+			id = "target:" + label + "@" + owners[target]
+			typeCount[id]++
+			if typeCount[id] > 1 {
+				id = fmt.Sprintf("%s#%d", id, typeCount[id])
+			}
+			targetIDs[target] = id
+			addNode(GraphNode{ID: id, Kind: NodeTarget, Label: label, Unsatisfied: unsatisfied[target]})
+		}
+		return id
+	}
+	addEdge := func(edge GraphEdge) {
+		if !edges[edge] {
+			edges[edge] = true
+			g.Edges = append(g.Edges, edge)
+		}
+	}
+
+	for _, req := range s.Requirements {
+		from := pkgNode(req.Package)
+		to := targetNode(req.Target, req.TargetType.String())
+		addEdge(GraphEdge{From: from, To: to, Kind: EdgeRequires, Unsatisfied: unsatisfied[req.Target]})
+	}
+	for _, prov := range s.Provisions {
+		from := pkgNode(prov.Package)
+		to := targetNode(prov.Target, prov.TargetType.String())
+		addEdge(GraphEdge{From: from, To: to, Kind: EdgeProvides})
+	}
+	for _, kv := range s.KeyValues {
+		from := pkgNode(kv.Package)
+		to := targetNode(kv.Target, kv.TargetType.String())
+		addEdge(GraphEdge{From: from, To: to, Kind: EdgeExtends})
+	}
+	for _, elem := range s.SliceElements {
+		from := pkgNode(elem.Package)
+		to := targetNode(elem.Target, elem.TargetType.String())
+		addEdge(GraphEdge{From: from, To: to, Kind: EdgeExtends})
+	}
+	return g
+}
+
+// targetOwners returns the owner package of each target: the first by name of requiring packages,
+// of providing ones if there are no requirements, of extending ones otherwise
+func targetOwners(s RegistrySnapshot) map[interface{}]string {
+	res := make(map[interface{}]string)
+	rank := make(map[interface{}]int)
+	own := func(target interface{}, pkg string, r int) {
+		if cur, ok := rank[target]; !ok || r < cur || r == cur && pkg < res[target] {
+			res[target] = pkg
+			rank[target] = r
+		}
+	}
+	for _, req := range s.Requirements {
+		own(req.Target, req.Package, 0)
+	}
+	for _, prov := range s.Provisions {
+		own(prov.Target, prov.Package, 1)
+	}
+	for _, kv := range s.KeyValues {
+		own(kv.Target, kv.Package, 2)
+	}
+	for _, elem := range s.SliceElements {
+		own(elem.Target, elem.Package, 2)
+	}
+	return res
+}
+
+// DOT returns the graph in GraphViz format, unsatisfied requirements are red
+func (g Graph) DOT() string {
+	var buf bytes.Buffer
+	buf.WriteString("digraph godif {\n\trankdir=LR\n")
+	for _, node := range g.Nodes {
+		attrs := "shape=box"
+		if node.Kind == NodeTarget {
+			attrs = "shape=ellipse"
+		}
+		if node.Unsatisfied {
+			attrs += " color=red fontcolor=red"
+		}
+		buf.WriteString(fmt.Sprintf("\t%q [label=%q %s]\n", node.ID, node.Label, attrs))
+	}
+	for _, edge := range g.Edges {
+		attrs := ""
+		if edge.Unsatisfied {
+			attrs = " color=red style=dashed"
+		}
+		buf.WriteString(fmt.Sprintf("\t%q -> %q [label=%q%s]\n", edge.From, edge.To, edge.Kind, attrs))
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// Mermaid returns the graph as Mermaid flowchart, unsatisfied requirements have class "unsatisfied"
+func (g Graph) Mermaid() string {
+	var buf bytes.Buffer
+	buf.WriteString("graph LR\n")
+	ids := make(map[string]string)
+	var unsatisfied []string
+	for i, node := range g.Nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[node.ID] = id
+		label := strings.ReplaceAll(node.Label, `"`, "#quot;")
+		if node.Kind == NodeTarget {
+			buf.WriteString(fmt.Sprintf("\t%s([\"%s\"])\n", id, label))
+		} else {
+			buf.WriteString(fmt.Sprintf("\t%s[\"%s\"]\n", id, label))
+		}
+		if node.Unsatisfied {
+			unsatisfied = append(unsatisfied, id)
+		}
+	}
+	for _, edge := range g.Edges {
+		arrow := "-->"
+		if edge.Unsatisfied {
+			arrow = "-.->"
+		}
+		buf.WriteString(fmt.Sprintf("\t%s %s|%s| %s\n", ids[edge.From], arrow, edge.Kind, ids[edge.To]))
+	}
+	if len(unsatisfied) > 0 {
+		buf.WriteString("\tclassDef unsatisfied stroke:#f00,stroke-width:2px,color:#f00\n")
+		buf.WriteString(fmt.Sprintf("\tclass %s unsatisfied\n", strings.Join(unsatisfied, ",")))
+	}
+	return buf.String()
+}
+
+// JSON returns the graph as indented JSON
+func (g Graph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g, "", "  ")
+}
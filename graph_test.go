@@ -0,0 +1,101 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+	var missedFunc func(x int, y int) int
+	var mySlice []string
+
+	Require(&injectedFunc)
+	Require(&missedFunc)
+	Provide(&injectedFunc, f)
+	ProvideSliceElement(&mySlice, "str1")
+	ProvideSliceElement(&mySlice, "str2")
+
+	g := Registry().Graph()
+	require.Equal(t, []GraphNode{
+		{ID: "pkg:" + godifPkg, Kind: NodePackage, Label: godifPkg},
+		{ID: "target:func(int, int) int@" + godifPkg, Kind: NodeTarget, Label: "func(int, int) int"},
+		{ID: "target:func(int, int) int@" + godifPkg + "#2", Kind: NodeTarget, Label: "func(int, int) int", Unsatisfied: true},
+		{ID: "target:[]string@" + godifPkg, Kind: NodeTarget, Label: "[]string"},
+	}, g.Nodes)
+	require.Equal(t, []GraphEdge{
+		{From: "pkg:" + godifPkg, To: "target:func(int, int) int@" + godifPkg, Kind: EdgeRequires},
+		{From: "pkg:" + godifPkg, To: "target:func(int, int) int@" + godifPkg + "#2", Kind: EdgeRequires, Unsatisfied: true},
+		{From: "pkg:" + godifPkg, To: "target:func(int, int) int@" + godifPkg, Kind: EdgeProvides},
+		{From: "pkg:" + godifPkg, To: "target:[]string@" + godifPkg, Kind: EdgeExtends},
+	}, g.Edges)
+
+	require.Equal(t, `digraph godif {
+	rankdir=LR
+	"pkg:github.com/untillpro/godif" [label="github.com/untillpro/godif" shape=box]
+	"target:func(int, int) int@github.com/untillpro/godif" [label="func(int, int) int" shape=ellipse]
+	"target:func(int, int) int@github.com/untillpro/godif#2" [label="func(int, int) int" shape=ellipse color=red fontcolor=red]
+	"target:[]string@github.com/untillpro/godif" [label="[]string" shape=ellipse]
+	"pkg:github.com/untillpro/godif" -> "target:func(int, int) int@github.com/untillpro/godif" [label="requires"]
+	"pkg:github.com/untillpro/godif" -> "target:func(int, int) int@github.com/untillpro/godif#2" [label="requires" color=red style=dashed]
+	"pkg:github.com/untillpro/godif" -> "target:func(int, int) int@github.com/untillpro/godif" [label="provides"]
+	"pkg:github.com/untillpro/godif" -> "target:[]string@github.com/untillpro/godif" [label="extends"]
+}
+`, g.DOT())
+
+	require.Equal(t, `graph LR
+	n0["github.com/untillpro/godif"]
+	n1(["func(int, int) int"])
+	n2(["func(int, int) int"])
+	n3(["[]string"])
+	n0 -->|requires| n1
+	n0 -.->|requires| n2
+	n0 -->|provides| n1
+	n0 -->|extends| n3
+	classDef unsatisfied stroke:#f00,stroke-width:2px,color:#f00
+	class n2 unsatisfied
+`, g.Mermaid())
+
+	data, err := g.JSON()
+	require.Nil(t, err)
+	var parsed Graph
+	require.Nil(t, json.Unmarshal(data, &parsed))
+	require.Equal(t, g, parsed)
+}
+
+func TestGraphTargetIDsDoNotDependOnOrder(t *testing.T) {
+	var sum func(x int, y int) int
+	var mul func(x int, y int) int
+	funcType := reflect.TypeOf(sum)
+	reqs := []RequirementInfo{
+		{Target: &sum, TargetType: funcType, Package: "example.com/b", Injected: true},
+		{Target: &mul, TargetType: funcType, Package: "example.com/c", Injected: true},
+		{Target: &sum, TargetType: funcType, Package: "example.com/a", Injected: true},
+	}
+	ids := func(s RegistrySnapshot) map[string]bool {
+		res := make(map[string]bool)
+		for _, node := range s.Graph().Nodes {
+			res[node.ID] = true
+		}
+		return res
+	}
+
+	expected := map[string]bool{
+		"pkg:example.com/a": true, "pkg:example.com/b": true, "pkg:example.com/c": true,
+		"target:func(int, int) int@example.com/a": true,
+		"target:func(int, int) int@example.com/c": true,
+	}
+	require.Equal(t, expected, ids(RegistrySnapshot{Requirements: reqs}))
+	require.Equal(t, expected, ids(RegistrySnapshot{Requirements: []RequirementInfo{reqs[2], reqs[1], reqs[0]}}))
+}
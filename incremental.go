@@ -0,0 +1,302 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"reflect"
+
+	"github.com/untillpro/gochips/errs"
+)
+
+// ResolveIncremental validates and injects requirements and provisions registered since the last resolve, e.g. by late-loaded plugins
+// Injected implementations are never replaced, such provisions and bindings are reported by EAlreadyInjected
+// Data provided by ProvideKeyValue() and ProvideSliceElement() is added to copies of the storages which are assigned at once
+// Works as ResolveAll() if the container is not resolved yet
+func (c *Container) ResolveIncremental() errs.Errors {
+	return c.resolveIncremental()
+}
+
+// ResolveIncremental validates and injects requirements and provisions registered in the default container since the last resolve
+func ResolveIncremental() errs.Errors {
+	return defaultContainer.resolveIncremental()
+}
+
+func (c *Container) resolveIncremental() errs.Errors {
+	c.lock()
+	defer c.mu.Unlock()
+	for p := c.parent; p != nil; p = p.parent {
+		p.lock()
+		defer p.mu.Unlock()
+	}
+
+	if c.resolveSrc == nil {
+		if errs, _ := c.resolve(Options{}); errs != nil {
+			return errs
+		}
+		_, file, line := caller(2)
+		c.resolveSrc = &src{file, line}
+		return nil
+	}
+
+	// provisions of the profiles which were active on the first resolve
+	c.activateProfiles(c.profiles)
+	if errs := c.validateIncremental(); errs != nil {
+		return sortErrors(errs)
+	}
+
+	c.setResolving(goroutineID())
+	defer c.setResolving(0)
+	if errs := c.runConstructors(); errs != nil {
+		return sortErrors(errs)
+	}
+
+	c.injectIncremental()
+	c.injectBindings()
+	c.markResolved()
+	return nil
+}
+
+// markResolved remembers everything which is registered at the moment, so ResolveIncremental() skips it
+func (c *Container) markResolved() {
+	for _, provs := range c.provided {
+		for _, prov := range provs {
+			c.resolvedProvs[prov] = true
+		}
+	}
+	for _, decs := range c.decorators {
+		for _, dec := range decs {
+			c.resolvedProvs[dec.srcPkgElem] = true
+		}
+	}
+	for target := range c.required {
+		c.resolvedReqs[target] = true
+	}
+	for _, kv := range c.keyValues {
+		for _, elems := range kv {
+			for _, elem := range elems {
+				c.resolvedElems[elem] = true
+			}
+		}
+	}
+	for _, elems := range c.sliceElements {
+		for _, elem := range elems {
+			c.resolvedElems[elem] = true
+		}
+	}
+	for _, bindings := range c.bindings {
+		for _, b := range bindings {
+			c.resolvedElems[b.srcElem] = true
+		}
+	}
+}
+
+// lookupInjected returns provision injected into the target by the container or by the nearest ancestor
+func (c *Container) lookupInjected(target interface{}) *srcPkgElem {
+	for cur := c; cur != nil; cur = cur.parent {
+		if prov, ok := cur.injected[target]; ok {
+			return prov
+		}
+	}
+	return nil
+}
+
+// hasNewProvisions returns true if provisions or decorators were registered for the target since the last resolve
+func (c *Container) hasNewProvisions(target interface{}) bool {
+	for _, prov := range c.provided[target] {
+		if !c.resolvedProvs[prov] {
+			return true
+		}
+	}
+	for _, dec := range c.decorators[target] {
+		if !c.resolvedProvs[dec.srcPkgElem] {
+			return true
+		}
+	}
+	return false
+}
+
+// newKeyValues returns key values registered since the last resolve and keys in provision order
+func (c *Container) newKeyValues(targetMap interface{}) (kv map[interface{}][]*srcElem, keys []interface{}) {
+	for _, key := range c.keyOrder[targetMap] {
+		for _, elem := range c.keyValues[targetMap][key] {
+			if c.resolvedElems[elem] {
+				continue
+			}
+			if kv == nil {
+				kv = make(map[interface{}][]*srcElem)
+			}
+			if _, ok := kv[key]; !ok {
+				keys = append(keys, key)
+			}
+			kv[key] = append(kv[key], elem)
+		}
+	}
+	return kv, keys
+}
+
+func (c *Container) newSliceElements(targetSlice interface{}) (elems []*srcElem) {
+	for _, elem := range c.sliceElements[targetSlice] {
+		if !c.resolvedElems[elem] {
+			elems = append(elems, elem)
+		}
+	}
+	return elems
+}
+
+// validateIncremental validates changes made since the last resolve, package usage is not checked
+func (c *Container) validateIncremental() (errs errs.Errors) {
+	if errs = c.validateHashable(); errs != nil {
+		return errs
+	}
+
+	errs = append(errs, c.validateOverrides()...)
+
+	targets := make(map[interface{}]bool)
+	for target := range c.provided {
+		targets[target] = true
+	}
+	for target := range c.decorators {
+		targets[target] = true
+	}
+	for target := range targets {
+		injected := c.lookupInjected(target)
+		if injected == nil || !c.hasNewProvisions(target) {
+			continue
+		}
+		newDecorators := false
+		for _, dec := range c.decorators[target] {
+			newDecorators = newDecorators || !c.resolvedProvs[dec.srcPkgElem]
+		}
+		provs := c.effectiveProvided(target)
+		if len(provs) == 1 && provs[0] == injected && !newDecorators {
+			// e.g. new default yields to the injected implementation
+			continue
+		}
+		for _, prov := range provs {
+			if !c.resolvedProvs[prov] {
+				errs.AddE(&EAlreadyInjected{prov, injected})
+			}
+		}
+		for _, dec := range c.decorators[target] {
+			if !c.resolvedProvs[dec.srcPkgElem] {
+				errs.AddE(&EAlreadyInjected{dec.srcPkgElem, injected})
+			}
+		}
+	}
+
+	requiredPackages := make(map[string]bool)
+	for target, req := range c.requiredView() {
+		if c.lookupInjected(target) != nil || (c.resolvedReqs[target] && !c.hasNewProvisions(target)) {
+			continue
+		}
+		errs = append(errs, c.validateRequirement(req, requiredPackages)...)
+	}
+
+	// as validate() does, new implementation of non-nil slice or of non-nil map with key-value data is an error
+	for target, provs := range c.provided {
+		provs = c.selectProvs(target, provs)
+		if provs == nil || c.resolvedProvs[provs[0]] || c.lookupInjected(target) != nil {
+			continue
+		}
+		targetValue := reflect.ValueOf(target).Elem()
+		_, hasKeyValues := c.keyValues[target]
+		if (targetValue.Kind() == reflect.Slice || targetValue.Kind() == reflect.Map && hasKeyValues) && !targetValue.IsNil() {
+			errs.AddE(&EImplementationProvidedForNonNil{provs[0]})
+		}
+	}
+
+	for targetMap := range c.keyValues {
+		kv, keys := c.newKeyValues(targetMap)
+		if kv == nil {
+			continue
+		}
+		if reflect.ValueOf(targetMap).Elem().IsNil() && c.effectiveProvided(targetMap) == nil {
+			errs.AddE(&EImplementationNotProvided{kv[keys[0]][0], targetMap})
+			continue
+		}
+		// all values of the keys are validated, so a new value of the key which is already set is reported by EMultipleValues
+		affected := make(map[interface{}][]*srcElem)
+		for key := range kv {
+			affected[key] = c.keyValues[targetMap][key]
+		}
+		errs = append(errs, validateKeyValueTypes(reflect.TypeOf(targetMap).Elem(), affected)...)
+	}
+
+	for targetSlice := range c.sliceElements {
+		if elems := c.newSliceElements(targetSlice); elems != nil {
+			errs = append(errs, c.validateSliceElements(reflect.TypeOf(targetSlice).Elem(), elems)...)
+		}
+	}
+
+	errs = append(errs, c.validateConstructors(requiredPackages)...)
+	errs = append(errs, c.validateLazy()...)
+	errs = append(errs, c.validateDecorators()...)
+	errs = append(errs, c.validateRebindings()...)
+	errs = append(errs, c.validateBindings()...)
+	return errs
+}
+
+func (c *Container) injectIncremental() {
+	assign := func(target interface{}, value reflect.Value) {
+		if c.parent != nil {
+			c.setTarget(target, value)
+		} else {
+			reflect.ValueOf(target).Elem().Set(value)
+		}
+	}
+
+	needed := make(map[interface{}]bool)
+	for target := range c.requiredView() {
+		needed[target] = true
+	}
+	for target := range c.provided {
+		if _, ok := c.keyValues[target]; ok {
+			needed[target] = true
+		}
+		if _, ok := c.sliceElements[target]; ok {
+			needed[target] = true
+		}
+	}
+	for target := range needed {
+		if c.lookupInjected(target) != nil || c.isBound(target) || !isEmpty(reflect.ValueOf(target).Elem()) {
+			continue
+		}
+		if provs := c.effectiveProvided(target); provs != nil {
+			assign(target, c.decoratedValue(target, provs[0]))
+			c.injected[target] = provs[0]
+		}
+	}
+
+	for targetMap := range c.keyValues {
+		kv, keys := c.newKeyValues(targetMap)
+		if kv == nil {
+			continue
+		}
+		baseMap := reflect.ValueOf(targetMap).Elem()
+		newMap := reflect.New(baseMap.Type()).Elem()
+		newMap.Set(reflect.MakeMapWithSize(baseMap.Type(), baseMap.Len()))
+		iter := baseMap.MapRange()
+		for iter.Next() {
+			newMap.SetMapIndex(iter.Key(), iter.Value())
+		}
+		appendKeyValues(newMap, kv, keys)
+		assign(targetMap, newMap)
+	}
+
+	for targetSlice := range c.sliceElements {
+		elems := c.newSliceElements(targetSlice)
+		if elems == nil {
+			continue
+		}
+		baseSlice := reflect.ValueOf(targetSlice).Elem()
+		newSlice := reflect.New(baseSlice.Type()).Elem()
+		newSlice.Set(reflect.AppendSlice(newSlice, baseSlice))
+		appendSliceElements(newSlice, c.orderedSliceElements(elems))
+		assign(targetSlice, newSlice)
+	}
+}
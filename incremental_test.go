@@ -0,0 +1,182 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveIncremental(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+	var pluginFunc func(x int, y int) int
+	var mySlice []string
+	var myMap map[string][]int
+
+	Require(&injectedFunc)
+	Provide(&injectedFunc, f)
+	ProvideSliceElement(&mySlice, "str1")
+	Provide(&myMap, map[string][]int{})
+	ProvideKeyValue(&myMap, "key1", 1)
+	require.Nil(t, ResolveAll())
+	oldSlice := mySlice
+	oldMap := myMap
+
+	// nothing new
+	require.Nil(t, ResolveIncremental())
+
+	// plugin
+	Require(&pluginFunc)
+	Provide(&pluginFunc, f3)
+	ProvideSliceElement(&mySlice, "str2")
+	ProvideKeyValue(&myMap, "key1", 2)
+	ProvideKeyValue(&myMap, "key2", 3)
+	require.Nil(t, ResolveIncremental())
+
+	require.Equal(t, 5, injectedFunc(3, 2))
+	require.Equal(t, 6, pluginFunc(3, 2))
+	require.Equal(t, []string{"str1", "str2"}, mySlice)
+	require.Equal(t, map[string][]int{"key1": {1, 2}, "key2": {3}}, myMap)
+
+	// storages are replaced by copies
+	require.Equal(t, []string{"str1"}, oldSlice)
+	require.Equal(t, map[string][]int{"key1": {1}}, oldMap)
+
+	Reset()
+	require.Nil(t, injectedFunc)
+	require.Nil(t, pluginFunc)
+}
+
+func TestResolveIncrementalWorksAsResolveAll(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	Require(&injectedFunc)
+	Provide(&injectedFunc, f)
+	require.Nil(t, ResolveIncremental())
+	require.Equal(t, 5, injectedFunc(3, 2))
+
+	errs := ResolveAll()
+	if _, ok := errs[0].(*EAlreadyResolved); !ok || len(errs) != 1 {
+		t.Fatal(errs)
+	}
+}
+
+func TestResolveIncrementalErrorOnReplace(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+	var mySlice []string
+
+	Require(&injectedFunc)
+	_, injectedFile, injectedLine, _ := runtime.Caller(0)
+	Provide(&injectedFunc, f)
+	require.Nil(t, ResolveAll())
+
+	_, file, line, _ := runtime.Caller(0)
+	Provide(&injectedFunc, f3)
+	ProvideDecorator(&injectedFunc, func(next func(x int, y int) int) func(x int, y int) int { return next })
+	ProvideDefault(&injectedFunc, f3)
+	ProvideSliceElement(&mySlice, "str1")
+
+	errs := ResolveIncremental()
+	require.Len(t, errs, 2, errs)
+	for i, err := range errs {
+		if e, ok := err.(*EAlreadyInjected); ok {
+			require.Equal(t, file, e.prov.file)
+			require.Equal(t, line+i+1, e.prov.line)
+			require.Equal(t, injectedFile, e.injected.file)
+			require.Equal(t, injectedLine+1, e.injected.line)
+		} else {
+			t.Fatal(errs)
+		}
+	}
+
+	// nothing is injected
+	require.Equal(t, 5, injectedFunc(3, 2))
+	require.Nil(t, mySlice)
+}
+
+func TestResolveIncrementalErrorOnKeyValue(t *testing.T) {
+	Reset()
+	var myMap map[string]int
+	var pluginMap map[string]int
+
+	Provide(&myMap, map[string]int{})
+	ProvideKeyValue(&myMap, "key1", 1)
+	require.Nil(t, ResolveAll())
+
+	ProvideKeyValue(&myMap, "key1", 2)
+	ProvideKeyValue(&pluginMap, "key1", 1)
+	errs := ResolveIncremental()
+	require.Len(t, errs, 2, errs)
+	if _, ok := errs[0].(*EMultipleValues); !ok {
+		t.Fatal(errs)
+	}
+	if _, ok := errs[1].(*EImplementationNotProvided); !ok {
+		t.Fatal(errs)
+	}
+	require.Equal(t, map[string]int{"key1": 1}, myMap)
+}
+
+func TestResolveIncrementalErrorOnNonNil(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+	mySlice := []int{1}
+	myMap := map[string]int{"key1": 1}
+
+	Require(&injectedFunc)
+	Provide(&injectedFunc, f)
+	require.Nil(t, ResolveAll())
+
+	_, _, line, _ := runtime.Caller(0)
+	Provide(&mySlice, []int{2})
+	Provide(&myMap, map[string]int{})
+	ProvideKeyValue(&myMap, "key2", 2)
+	errs := ResolveIncremental()
+	require.Len(t, errs, 2, errs)
+	for i, err := range errs {
+		if e, ok := err.(*EImplementationProvidedForNonNil); ok {
+			require.Equal(t, line+i+1, e.prov.line)
+		} else {
+			t.Fatal(errs)
+		}
+	}
+	require.Equal(t, []int{1}, mySlice)
+	require.Equal(t, map[string]int{"key1": 1}, myMap)
+}
+
+func TestResolveIncrementalInChild(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+	var pluginFunc func(x int, y int) int
+	var mySlice []string
+
+	Require(&injectedFunc)
+	Provide(&injectedFunc, f)
+	ProvideSliceElement(&mySlice, "parent")
+	require.Nil(t, ResolveAll())
+
+	child := NewChild()
+	child.ProvideSliceElement(&mySlice, "child1")
+	require.Nil(t, child.ResolveAll())
+
+	child.Require(&pluginFunc)
+	child.Provide(&pluginFunc, f3)
+	child.ProvideSliceElement(&mySlice, "child2")
+	require.Nil(t, child.ResolveIncremental())
+	require.Equal(t, 6, pluginFunc(3, 2))
+	require.Equal(t, []string{"parent", "child1", "child2"}, mySlice)
+
+	child.Reset()
+	require.Nil(t, pluginFunc)
+	require.Equal(t, []string{"parent"}, mySlice)
+	require.Equal(t, 5, injectedFunc(3, 2))
+}
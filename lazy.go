@@ -0,0 +1,151 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/untillpro/gochips/errs"
+)
+
+// ProvideLazy registers factory of ref implementation, e.g. func() T or func() (T, error), ref must point to func or to *Lazy[T]
+// For func target ResolveAll() injects trampoline which calls the factory at most once, on the first call, and then calls the built implementation
+// Trampoline keeps calling the built implementation by reflection, use *Lazy[T] target for hot paths
+// For *Lazy[T] target ResolveAll() injects holder which calls the factory on the first Get()
+// If factory fails or panics the trampoline or Get() panics with ELazyFailed on every call
+func (c *Container) ProvideLazy(ref interface{}, factory interface{}) {
+	c.provideLazy(ref, factory)
+}
+
+// ProvideLazy registers factory of ref implementation in the default container
+func ProvideLazy(ref interface{}, factory interface{}) {
+	defaultContainer.provideLazy(ref, factory)
+}
+
+func (c *Container) provideLazy(ref interface{}, factory interface{}) {
+	prov := callerSrcPkgElem(3, factory)
+	prov.lazy = true
+	if t := reflect.TypeOf(ref); t != nil && t.Kind() == reflect.Ptr && t.Elem().Implements(lazyHolderType) {
+		prov.holder = t.Elem()
+	}
+	c.addProvision(ref, prov)
+}
+
+// Lazy holds implementation of pointer or interface T which is built on the first Get(), e.g. var Pool *godif.Lazy[*sql.DB]
+type Lazy[T any] struct {
+	once    sync.Once
+	build   func() (reflect.Value, *ELazyFailed)
+	impl    T
+	failure *ELazyFailed
+}
+
+// Get calls the factory at most once, on the first call, safe for concurrent calls
+// Returns zero T if the holder is not injected, panics with ELazyFailed on every call if factory fails or panics
+func (l *Lazy[T]) Get() T {
+	if l == nil {
+		var zero T
+		return zero
+	}
+	l.once.Do(func() {
+		var impl reflect.Value
+		impl, l.failure = l.build()
+		if l.failure == nil {
+			l.impl = impl.Interface().(T)
+		}
+	})
+	if l.failure != nil {
+		panic(l.failure)
+	}
+	return l.impl
+}
+
+func (l *Lazy[T]) lazyImplType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (l *Lazy[T]) setFactory(build func() (reflect.Value, *ELazyFailed)) {
+	l.build = build
+}
+
+// lazyHolder is implemented by *Lazy[T] of any T
+type lazyHolder interface {
+	lazyImplType() reflect.Type
+	setFactory(build func() (reflect.Value, *ELazyFailed))
+}
+
+var lazyHolderType = reflect.TypeOf((*lazyHolder)(nil)).Elem()
+
+// isLazyFactory checks factory of the holder or, if holder is nil, of func target
+func isLazyFactory(t reflect.Type, holder reflect.Type) bool {
+	if !isConstructor(t) || t.NumIn() != 0 {
+		return false
+	}
+	if holder == nil {
+		return t.Out(0).Kind() == reflect.Func
+	}
+	return t.Out(0).AssignableTo(reflect.Zero(holder).Interface().(lazyHolder).lazyImplType())
+}
+
+func (c *Container) validateLazy() (errs errs.Errors) {
+	for target, provs := range c.provided {
+		for _, prov := range c.selectProvs(target, provs) {
+			if !prov.lazy || c.resolvedProvs[prov] {
+				continue
+			}
+			if !isLazyFactory(reflect.TypeOf(prov.elem), prov.holder) || prov.holder == nil && reflect.TypeOf(target).Elem().Kind() != reflect.Func {
+				errs.AddE(&EInvalidLazyProvision{prov})
+			}
+		}
+	}
+	return errs
+}
+
+// lazyTrampoline returns func which builds implementation on the first call and forwards all calls to it
+func (p *srcPkgElem) lazyTrampoline() reflect.Value {
+	var once sync.Once
+	var impl reflect.Value
+	var failure *ELazyFailed
+	implType := p.implType()
+	return reflect.MakeFunc(implType, func(args []reflect.Value) []reflect.Value {
+		once.Do(func() {
+			impl, failure = p.buildLazy()
+		})
+		if failure != nil {
+			panic(failure)
+		}
+		if implType.IsVariadic() {
+			return impl.CallSlice(args)
+		}
+		return impl.Call(args)
+	})
+}
+
+// newLazyHolder returns *Lazy[T] which builds implementation on the first Get()
+func (p *srcPkgElem) newLazyHolder() reflect.Value {
+	v := reflect.New(p.holder.Elem())
+	v.Interface().(lazyHolder).setFactory(p.buildLazy)
+	return v
+}
+
+func (p *srcPkgElem) buildLazy() (impl reflect.Value, failure *ELazyFailed) {
+	defer func() {
+		if r := recover(); r != nil {
+			failure = &ELazyFailed{p, fmt.Errorf("panic: %v", r)}
+		}
+	}()
+	res := reflect.ValueOf(p.elem).Call(nil)
+	if len(res) == 2 && !res[1].IsNil() {
+		return impl, &ELazyFailed{p, res[1].Interface().(error)}
+	}
+	if isService(res[0].Kind()) && res[0].IsNil() {
+		return impl, &ELazyFailed{p, fmt.Errorf("factory returned nil")}
+	}
+	return res[0], nil
+}
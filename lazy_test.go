@@ -0,0 +1,223 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazyBasic(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+	var calls int32
+
+	Require(&injectedFunc)
+	ProvideLazy(&injectedFunc, func() func(x int, y int) int {
+		atomic.AddInt32(&calls, 1)
+		return f
+	})
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, int32(0), atomic.LoadInt32(&calls))
+	require.Contains(t, Report(), "(lazy)")
+
+	results := make(chan int, 20)
+	for i := 0; i < 20; i++ {
+		go func() {
+			results <- injectedFunc(3, 2)
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		require.Equal(t, 5, <-results)
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	Reset()
+	require.Nil(t, injectedFunc)
+}
+
+func TestLazyFailure(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+	var panicking func(x int, y int) int
+
+	Require(&injectedFunc)
+	Require(&panicking)
+	_, file, line, _ := runtime.Caller(0)
+	ProvideLazy(&injectedFunc, func() (func(x int, y int) int, error) {
+		return nil, errors.New("no connection")
+	})
+	ProvideLazy(&panicking, func() func(x int, y int) int {
+		panic("boom")
+	})
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+
+	for i := 0; i < 2; i++ {
+		func() {
+			defer func() {
+				e, ok := recover().(*ELazyFailed)
+				require.True(t, ok)
+				require.Equal(t, file, e.prov.file)
+				require.Equal(t, line+1, e.prov.line)
+				require.Equal(t, "no connection", e.err.Error())
+			}()
+			injectedFunc(3, 2)
+		}()
+	}
+
+	defer func() {
+		e, ok := recover().(*ELazyFailed)
+		require.True(t, ok)
+		require.Equal(t, line+4, e.prov.line)
+		require.Contains(t, e.Error(), "boom")
+	}()
+	panicking(3, 2)
+}
+
+func TestLazyErrorOnInvalidFactory(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+	var store IStore
+
+	Require(&injectedFunc)
+	Require(&store)
+	_, file, line, _ := runtime.Caller(0)
+	ProvideLazy(&injectedFunc, func(x int) func(x int, y int) int { return f })
+	ProvideLazy(&store, func() IStore { return &memStore{} })
+
+	errs := ResolveAll()
+	require.Len(t, errs, 2, errs)
+	lines := map[int]bool{}
+	for _, err := range errs {
+		e, ok := err.(*EInvalidLazyProvision)
+		require.True(t, ok, errs)
+		require.Equal(t, file, e.prov.file)
+		lines[e.prov.line] = true
+	}
+	require.Equal(t, map[int]bool{line + 1: true, line + 2: true}, lines)
+}
+
+func TestLazyErrorOnIncompatibleTypes(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	Require(&injectedFunc)
+	ProvideLazy(&injectedFunc, func() func(x float32) float32 { return f2 })
+
+	errs := ResolveAll()
+	if _, ok := errs[0].(*EIncompatibleTypesFunc); !ok || len(errs) != 1 {
+		t.Fatal(errs)
+	}
+}
+
+func TestLazyAsConstructorDependency(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+	var getter func() int
+	calls := 0
+
+	Require(&getter)
+	ProvideLazy(&injectedFunc, func() func(x int, y int) int {
+		calls++
+		return f
+	})
+	ProvideConstructor(&getter, func(sum func(x int, y int) int) func() int {
+		return func() int { return sum(3, 2) }
+	})
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 0, calls)
+	require.Equal(t, 5, getter())
+	require.Equal(t, 5, getter())
+	require.Equal(t, 1, calls)
+}
+
+func TestLazyHolder(t *testing.T) {
+	Reset()
+	var pool *Lazy[*memStore]
+	var store *Lazy[IStore]
+	var calls int32
+
+	Require(&pool)
+	Require(&store)
+	ProvideLazy(&pool, func() (*memStore, error) {
+		atomic.AddInt32(&calls, 1)
+		return &memStore{}, nil
+	})
+	ProvideLazy(&store, func() *memStore { return &memStore{} })
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, int32(0), atomic.LoadInt32(&calls))
+
+	results := make(chan *memStore, 20)
+	for i := 0; i < 20; i++ {
+		go func() {
+			results <- pool.Get()
+		}()
+	}
+	first := <-results
+	require.NotNil(t, first)
+	for i := 1; i < 20; i++ {
+		require.True(t, first == <-results)
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	require.IsType(t, &memStore{}, store.Get())
+
+	Reset()
+	require.Nil(t, pool)
+	require.Nil(t, pool.Get())
+}
+
+func TestLazyHolderFailure(t *testing.T) {
+	Reset()
+	var pool *Lazy[*memStore]
+
+	Require(&pool)
+	_, _, line, _ := runtime.Caller(0)
+	ProvideLazy(&pool, func() *memStore { return nil })
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+
+	for i := 0; i < 2; i++ {
+		func() {
+			defer func() {
+				e, ok := recover().(*ELazyFailed)
+				require.True(t, ok)
+				require.Equal(t, line+1, e.prov.line)
+			}()
+			pool.Get()
+		}()
+	}
+}
+
+func TestLazyHolderErrorOnInvalidFactory(t *testing.T) {
+	Reset()
+	var pool *Lazy[*memStore]
+
+	Require(&pool)
+	_, _, line, _ := runtime.Caller(0)
+	ProvideLazy(&pool, func() IStore { return &memStore{} })
+
+	errs := ResolveAll()
+	if e, ok := errs[0].(*EInvalidLazyProvision); ok && len(errs) == 1 {
+		require.Equal(t, line+1, e.prov.line)
+	} else {
+		t.Fatal(errs)
+	}
+}
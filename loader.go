@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"reflect"
+
+	"github.com/untillpro/gochips/errs"
+)
+
+type loader struct {
+	*srcPkgElem
+	target interface{}
+}
+
+// ProvideLoader registers loader of map or slice target, e.g. func(keys map[string]*Key) error
+// ResolveAll() calls loaders after validation and before constructors with the value which will be injected into ref,
+// so loaders can fill vars which constructors and decorators use, e.g. load config values into vars described by key-value data
+// Loader error fails resolution and nothing is injected, but vars filled by loaders are kept
+func (c *Container) ProvideLoader(ref interface{}, ld interface{}) {
+	c.provideLoader(ref, ld)
+}
+
+// ProvideLoader registers loader of map or slice target in the default container
+func ProvideLoader(ref interface{}, ld interface{}) {
+	defaultContainer.provideLoader(ref, ld)
+}
+
+func (c *Container) provideLoader(ref interface{}, ld interface{}) {
+	prov := callerSrcPkgElem(3, ld)
+	c.lock()
+	defer c.mu.Unlock()
+	if isHashable(ref) && reflect.TypeOf(ref).Kind() == reflect.Ptr {
+		c.loaders = append(c.loaders, &loader{prov, ref})
+	} else {
+		c.unhashableProvs = append(c.unhashableProvs, prov.src)
+	}
+}
+
+func isLoaderOf(loaderType reflect.Type, targetType reflect.Type) bool {
+	return (targetType.Kind() == reflect.Map || targetType.Kind() == reflect.Slice) && loaderType != nil && loaderType.Kind() == reflect.Func &&
+		loaderType.NumIn() == 1 && loaderType.NumOut() == 1 && !loaderType.IsVariadic() &&
+		targetType.AssignableTo(loaderType.In(0)) && loaderType.Out(0) == errorType
+}
+
+func (c *Container) validateLoaders() (errs errs.Errors) {
+	for _, l := range c.loaders {
+		if !isLoaderOf(reflect.TypeOf(l.elem), reflect.TypeOf(l.target).Elem()) {
+			errs.AddE(&EIncompatibleTypesLoader{reflect.TypeOf(l.target).Elem(), l.srcPkgElem})
+		}
+	}
+	return errs
+}
+
+// runLoaders calls loaders in provision order, errs.Errors returned by a loader are flattened
+func (c *Container) runLoaders() (res errs.Errors) {
+	for _, l := range c.loaders {
+		out, reentrant := callProvided(l.srcPkgElem, []reflect.Value{c.storageValue(l.target)})
+		if reentrant != nil {
+			res.AddE(reentrant)
+			continue
+		}
+		if out[0].IsNil() {
+			continue
+		}
+		if loaderErrs, ok := out[0].Interface().(errs.Errors); ok {
+			res = append(res, loaderErrs...)
+		} else {
+			res.AddE(out[0].Interface().(error))
+		}
+	}
+	return res
+}
+
+// storageValue returns copy of the map or slice which inject() assigns to the target
+func (c *Container) storageValue(target interface{}) reflect.Value {
+	targetValue := reflect.ValueOf(target).Elem()
+	base := targetValue
+	if base.IsNil() {
+		if provs := c.effectiveProvided(target); provs != nil {
+			base = reflect.ValueOf(provs[0].elem)
+		}
+	}
+	res := reflect.New(targetValue.Type()).Elem()
+	if targetValue.Kind() == reflect.Map {
+		res.Set(reflect.MakeMapWithSize(targetValue.Type(), base.Len()))
+		iter := base.MapRange()
+		for iter.Next() {
+			res.SetMapIndex(iter.Key(), iter.Value())
+		}
+		appendKeyValues(res, c.keyValues[target], c.keyOrder[target])
+	} else {
+		res.Set(reflect.AppendSlice(res, base))
+		appendSliceElements(res, c.orderedSliceElements(c.sliceElements[target]))
+	}
+	return res
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/untillpro/gochips/errs"
+)
+
+func TestLoaderRunsBeforeConstructors(t *testing.T) {
+	Reset()
+	var settings map[string]int
+	var names []string
+	var port int
+	var getter func() int
+
+	Require(&getter)
+	ProvideKeyValue(&settings, "port", 8080)
+	Provide(&settings, map[string]int{"debug": 1})
+	ProvideSliceElement(&names, "name1")
+	ProvideLoader(&settings, func(s map[string]int) error {
+		require.Equal(t, map[string]int{"debug": 1, "port": 8080}, s)
+		port = s["port"]
+		return nil
+	})
+	ProvideLoader(&names, func(n []string) error {
+		require.Equal(t, []string{"name1"}, n)
+		return nil
+	})
+	ProvideConstructor(&getter, func() func() int {
+		p := port
+		return func() int { return p }
+	})
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 8080, getter())
+	require.Equal(t, map[string]int{"debug": 1, "port": 8080}, settings)
+}
+
+func TestLoaderErrors(t *testing.T) {
+	Reset()
+	var settings map[string]int
+	var getter func() int
+
+	Require(&getter)
+	Provide(&settings, map[string]int{})
+	ProvideKeyValue(&settings, "port", 8080)
+	ProvideLoader(&settings, func(s map[string]int) error {
+		return errs.Errors{errors.New("err1"), errors.New("err2")}
+	})
+	ProvideLoader(&settings, func(s map[string]int) error {
+		return errors.New("err3")
+	})
+	ProvideConstructor(&getter, func() func() int {
+		t.Fatal("constructor is called")
+		return nil
+	})
+
+	errs := ResolveAll()
+	require.Len(t, errs, 3, errs)
+	require.Equal(t, "err1", errs[0].Error())
+	require.Nil(t, settings)
+	require.Nil(t, getter)
+}
+
+func TestLoaderErrorOnIncompatibleTypes(t *testing.T) {
+	Reset()
+	var settings map[string]int
+	var injectedFunc func(x int, y int) int
+
+	Provide(&settings, map[string]int{})
+	ProvideKeyValue(&settings, "port", 8080)
+	Require(&injectedFunc)
+	Provide(&injectedFunc, f)
+	_, _, line, _ := runtime.Caller(0)
+	ProvideLoader(&settings, func(s map[string]string) error { return nil })
+	ProvideLoader(&injectedFunc, func(f func(x int, y int) int) error { return nil })
+
+	errs := ResolveAll()
+	require.Len(t, errs, 2, errs)
+	for i, err := range errs {
+		if e, ok := err.(*EIncompatibleTypesLoader); ok {
+			require.Equal(t, line+i+1, e.prov.line)
+		} else {
+			t.Fatal(errs)
+		}
+	}
+}
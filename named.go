@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"os"
+)
+
+type selection struct {
+	*src
+	name   string
+	envVar string
+}
+
+// selectedName returns empty string if nothing is selected
+func (s *selection) selectedName() string {
+	if len(s.envVar) > 0 {
+		return os.Getenv(s.envVar)
+	}
+	return s.name
+}
+
+// ProvideNamed registers named implementation of ref type
+// Named implementation is injected if it is selected by Select() or SelectFromEnv()
+// If nothing is selected unnamed implementations are used, if there are no unnamed ones all named ones are considered
+func (c *Container) ProvideNamed(ref interface{}, name string, implementation interface{}) {
+	c.provideNamed(ref, name, implementation)
+}
+
+// Select chooses named implementation which will be injected into ref
+func (c *Container) Select(ref interface{}, name string) {
+	c.selectNamed(ref, name, "")
+}
+
+// SelectFromEnv chooses named implementation which will be injected into ref by value of envVar environment variable
+// Variable is read on ResolveAll(), empty value means nothing is selected
+func (c *Container) SelectFromEnv(ref interface{}, envVar string) {
+	c.selectNamed(ref, "", envVar)
+}
+
+// ProvideNamed registers named implementation of ref type in the default container
+func ProvideNamed(ref interface{}, name string, implementation interface{}) {
+	defaultContainer.provideNamed(ref, name, implementation)
+}
+
+// Select chooses named implementation which will be injected into ref by the default container
+func Select(ref interface{}, name string) {
+	defaultContainer.selectNamed(ref, name, "")
+}
+
+// SelectFromEnv chooses named implementation which will be injected into ref by the default container by value of envVar environment variable
+func SelectFromEnv(ref interface{}, envVar string) {
+	defaultContainer.selectNamed(ref, "", envVar)
+}
+
+func (c *Container) provideNamed(ref interface{}, name string, implementation interface{}) {
+	prov := callerSrcPkgElem(3, implementation)
+	prov.name = name
+	c.addProvision(ref, prov)
+}
+
+func (c *Container) selectNamed(ref interface{}, name string, envVar string) {
+	_, file, line := caller(2)
+	c.lock()
+	defer c.mu.Unlock()
+	sel := &selection{&src{file, line}, name, envVar}
+	if isHashable(ref) {
+		c.selections[ref] = sel
+	} else {
+		c.unhashableProvs = append(c.unhashableProvs, sel.src)
+	}
+}
+
+// lookupSelection returns the selection made in the container or in the nearest ancestor, nil if nothing is selected
+func (c *Container) lookupSelection(target interface{}) *selection {
+	for cur := c; cur != nil; cur = cur.parent {
+		if sel, ok := cur.selections[target]; ok {
+			if len(sel.selectedName()) == 0 {
+				return nil
+			}
+			return sel
+		}
+	}
+	return nil
+}
+
+// selectProvs returns provisions which should be considered for the target
+// Override wins over all other provisions, defaults yield to regular provisions, then named provisions are filtered by selection
+func (c *Container) selectProvs(target interface{}, provs []*srcPkgElem) []*srcPkgElem {
+	if override := findOverride(provs); override != nil {
+		return []*srcPkgElem{override}
+	}
+	provs = withoutYieldedDefaults(provs)
+	sel := c.lookupSelection(target)
+	var res []*srcPkgElem
+	for _, prov := range provs {
+		if sel == nil && len(prov.name) == 0 || sel != nil && prov.name == sel.selectedName() {
+			res = append(res, prov)
+		}
+	}
+	if sel == nil && res == nil {
+		return provs
+	}
+	return res
+}
+
+// effectiveProvided returns selected provisions made in the container or, if there are none, in the nearest ancestor
+func (c *Container) effectiveProvided(target interface{}) []*srcPkgElem {
+	return c.selectProvs(target, c.lookupProvided(target))
+}
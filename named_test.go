@@ -0,0 +1,159 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamedSelect(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	Require(&injectedFunc)
+	ProvideNamed(&injectedFunc, "sum", f)
+	ProvideNamed(&injectedFunc, "mul", f3)
+	Select(&injectedFunc, "mul")
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 6, injectedFunc(3, 2))
+
+	Reset()
+	require.Nil(t, injectedFunc)
+}
+
+func TestNamedSelectFromEnv(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+	const envVar = "GODIF_TEST_NAMED_SELECT"
+
+	Require(&injectedFunc)
+	ProvideNamed(&injectedFunc, "sum", f)
+	ProvideNamed(&injectedFunc, "mul", f3)
+	SelectFromEnv(&injectedFunc, envVar)
+
+	os.Setenv(envVar, "sum")
+	defer os.Unsetenv(envVar)
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 5, injectedFunc(3, 2))
+}
+
+func TestNamedUnnamedIsUsedIfNothingSelected(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	Require(&injectedFunc)
+	Provide(&injectedFunc, f)
+	ProvideNamed(&injectedFunc, "mul", f3)
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 5, injectedFunc(3, 2))
+
+	// single named implementation is used if nothing selected
+	Reset()
+	Require(&injectedFunc)
+	ProvideNamed(&injectedFunc, "mul", f3)
+	errs = ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 6, injectedFunc(3, 2))
+}
+
+func TestNamedStorage(t *testing.T) {
+	Reset()
+	var myMap map[string]int
+
+	ProvideNamed(&myMap, "first", map[string]int{"first": 1})
+	ProvideNamed(&myMap, "second", map[string]int{"second": 2})
+	Select(&myMap, "second")
+	ProvideKeyValue(&myMap, "key", 3)
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, map[string]int{"second": 2, "key": 3}, myMap)
+}
+
+func TestNamedErrorOnMultipleNotSelected(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	Require(&injectedFunc)
+	ProvideNamed(&injectedFunc, "sum", f)
+	ProvideNamed(&injectedFunc, "mul", f3)
+
+	errs := ResolveAll()
+	if e, ok := errs[0].(*EMultipleFuncImplementations); ok && len(errs) == 1 {
+		fmt.Println(errs)
+		require.Equal(t, 2, len(e.provs))
+	} else {
+		t.Fatal(errs)
+	}
+	require.Nil(t, injectedFunc)
+}
+
+func TestNamedErrorOnSelectedNotProvided(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+	var myMap map[string]int
+
+	Require(&injectedFunc)
+	ProvideNamed(&injectedFunc, "sum", f)
+	_, selFile, selLine, _ := runtime.Caller(0)
+	Select(&injectedFunc, "div")
+
+	errs := ResolveAll()
+	if e, ok := errs[0].(*ENamedImplementationNotProvided); ok && len(errs) == 1 {
+		fmt.Println(errs)
+		require.Equal(t, selFile, e.sel.file)
+		require.Equal(t, selLine+1, e.sel.line)
+		require.Equal(t, &injectedFunc, e.target)
+	} else {
+		t.Fatal(errs)
+	}
+	require.Nil(t, injectedFunc)
+
+	// Not required target
+	Reset()
+	ProvideNamed(&myMap, "first", map[string]int{})
+	Select(&myMap, "second")
+	ProvideKeyValue(&myMap, "key", 3)
+	errs = ResolveAll()
+	if _, ok := errs[0].(*ENamedImplementationNotProvided); ok && len(errs) == 1 {
+		fmt.Println(errs)
+	} else {
+		t.Fatal(errs)
+	}
+}
+
+func TestNamedChildSelects(t *testing.T) {
+	t.Parallel()
+	parent := NewContainer()
+	var injectedFunc func(x int, y int) int
+
+	parent.Require(&injectedFunc)
+	parent.ProvideNamed(&injectedFunc, "sum", f)
+	parent.ProvideNamed(&injectedFunc, "mul", f3)
+	parent.Select(&injectedFunc, "sum")
+	require.Nil(t, parent.ResolveAll())
+	require.Equal(t, 5, injectedFunc(3, 2))
+
+	child := parent.NewChild()
+	child.Select(&injectedFunc, "mul")
+	require.Nil(t, child.ResolveAll())
+	require.Equal(t, 6, injectedFunc(3, 2))
+
+	child.Reset()
+	require.Equal(t, 5, injectedFunc(3, 2))
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+// RequireOptional registers dep which is left nil if implementation is not provided
+func (c *Container) RequireOptional(toInject interface{}) {
+	c.requireOptional(toInject)
+}
+
+// RequireOptionalOr registers dep which gets fallback if implementation is not provided
+// Fallback yields to all other provisions including ones provided by ProvideDefault()
+func (c *Container) RequireOptionalOr(toInject interface{}, fallback interface{}) {
+	c.requireOptionalOr(toInject, fallback)
+}
+
+// IsProvided returns true if implementation (but not the fallback given to RequireOptionalOr()) was injected into target
+func (c *Container) IsProvided(target interface{}) bool {
+	c.lock()
+	defer c.mu.Unlock()
+	for cur := c; cur != nil; cur = cur.parent {
+		if cur != c {
+			cur.lock()
+			defer cur.mu.Unlock()
+		}
+		if prov, ok := cur.injected[target]; ok {
+			return prov.kind != provisionFallback
+		}
+	}
+	return false
+}
+
+// RequireOptional registers dep in the default container which is left nil if implementation is not provided
+func RequireOptional(toInject interface{}) {
+	defaultContainer.requireOptional(toInject)
+}
+
+// RequireOptionalOr registers dep in the default container which gets fallback if implementation is not provided
+func RequireOptionalOr(toInject interface{}, fallback interface{}) {
+	defaultContainer.requireOptionalOr(toInject, fallback)
+}
+
+// IsProvided returns true if implementation was injected into target by the default container
+func IsProvided(target interface{}) bool {
+	return defaultContainer.IsProvided(target)
+}
+
+func (c *Container) requireOptional(toInject interface{}) {
+	pkg, file, line := caller(2)
+	c.addRequirement(newSrcElem(file, line, pkg, toInject), true)
+}
+
+func (c *Container) requireOptionalOr(toInject interface{}, fallback interface{}) {
+	prov := callerSrcPkgElem(3, fallback)
+	prov.kind = provisionFallback
+	c.addRequirement(newSrcElem(prov.file, prov.line, prov.pkg, toInject), true)
+	c.addProvision(toInject, prov)
+}
+
+// isOptional returns true if the nearest container which requires the target requires it as optional
+func (c *Container) isOptional(target interface{}) bool {
+	for cur := c; cur != nil; cur = cur.parent {
+		if optional, ok := cur.optional[target]; ok {
+			return optional
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionalNotProvided(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	RequireOptional(&injectedFunc)
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Nil(t, injectedFunc)
+	require.False(t, IsProvided(&injectedFunc))
+}
+
+func TestOptionalProvided(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	RequireOptional(&injectedFunc)
+	Provide(&injectedFunc, f)
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 5, injectedFunc(3, 2))
+	require.True(t, IsProvided(&injectedFunc))
+
+	Reset()
+	require.Nil(t, injectedFunc)
+	require.False(t, IsProvided(&injectedFunc))
+}
+
+func TestOptionalFallback(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	RequireOptionalOr(&injectedFunc, f3)
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 6, injectedFunc(3, 2))
+	require.False(t, IsProvided(&injectedFunc))
+	require.Contains(t, Report(), "(fallback)")
+
+	// fallback yields to default
+	Reset()
+	RequireOptionalOr(&injectedFunc, f3)
+	ProvideDefault(&injectedFunc, f)
+
+	errs = ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 5, injectedFunc(3, 2))
+	require.True(t, IsProvided(&injectedFunc))
+}
+
+func TestOptionalMandatoryWins(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	Require(&injectedFunc)
+	RequireOptional(&injectedFunc)
+
+	errs := ResolveAll()
+	if _, ok := errs[0].(*EImplementationNotProvided); ok && len(errs) == 1 {
+		fmt.Println(errs)
+	} else {
+		t.Fatal(errs)
+	}
+}
+
+func TestOptionalErrorOnIncompatibleFallback(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	RequireOptionalOr(&injectedFunc, f2)
+
+	errs := ResolveAll()
+	if _, ok := errs[0].(*EIncompatibleTypesFunc); ok && len(errs) == 1 {
+		fmt.Println(errs)
+	} else {
+		t.Fatal(errs)
+	}
+}
+
+func TestOptionalInChild(t *testing.T) {
+	t.Parallel()
+	parent := NewContainer()
+	var injectedFunc1 func(x int, y int) int
+	var injectedFunc2 func(x int, y int) int
+
+	parent.Require(&injectedFunc1)
+	parent.Provide(&injectedFunc1, f)
+	require.Nil(t, parent.ResolveAll())
+
+	child := parent.NewChild()
+	child.RequireOptional(&injectedFunc2)
+	require.Nil(t, child.ResolveAll())
+	require.Nil(t, injectedFunc2)
+	require.False(t, child.IsProvided(&injectedFunc2))
+	require.True(t, child.IsProvided(&injectedFunc1))
+}
+
+func TestOptionalDoesNotReplaceRequirement(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	Require(&injectedFunc)
+	_, _, line, _ := runtime.Caller(0)
+	RequireOptional(&injectedFunc)
+
+	// requirement stays mandatory and keeps its source
+	errs := ResolveAll()
+	if e, ok := errs[0].(*EImplementationNotProvided); ok && len(errs) == 1 {
+		require.Equal(t, line-1, e.req.line)
+	} else {
+		t.Fatal(errs)
+	}
+}
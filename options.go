@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"github.com/untillpro/gochips/errs"
+)
+
+// Severity of a diagnostic class
+type Severity int
+
+const (
+	// SeverityError fails resolution, default for all classes
+	SeverityError Severity = iota
+	// SeverityWarning is returned separately from errors and does not fail resolution
+	SeverityWarning
+	// SeverityIgnore drops the diagnostic
+	SeverityIgnore
+)
+
+// configurableCodes are diagnostics which do not prevent injection, severity of other ones can't be changed
+var configurableCodes = map[string]bool{
+	"EPackageNotUsed":                  true,
+	"EImplementationProvidedForNonNil": true,
+	"EOverrideWithoutBase":             true,
+	"ECyclicElementOrder":              true,
+	"EMultipleNamedElements":           true,
+}
+
+// Options of ResolveAllWithOptions()
+type Options struct {
+	// Severities by error code (see ErrorCode()), e.g. {"EPackageNotUsed": godif.SeverityWarning}
+	// Configurable codes are EPackageNotUsed, EImplementationProvidedForNonNil, EOverrideWithoutBase, ECyclicElementOrder and EMultipleNamedElements
+	Severities map[string]Severity
+	// Profiles which provisions registered by ProvideIn() are considered, nil means profiles are read from ProfilesEnvVar environment variable
+	Profiles []string
+	// CallerSkip is the number of wrapper functions between the caller and ResolveAllWithOptions(), e.g. 1 for a function which resolves on behalf of its caller
+	// The caller location is reported by EAlreadyResolved
+	CallerSkip int
+}
+
+// ResolveAllWithOptions works as ResolveAll() but diagnostics are classified by opts, warnings are returned separately
+// Targets are injected if there are no errors
+// Zero Options gives exactly the behavior of ResolveAll()
+func (c *Container) ResolveAllWithOptions(opts Options) (errors errs.Errors, warnings errs.Errors) {
+	return c.resolveAllWithOptions(opts)
+}
+
+// ResolveAllWithOptions resolves the default container, see Container.ResolveAllWithOptions()
+func ResolveAllWithOptions(opts Options) (errors errs.Errors, warnings errs.Errors) {
+	return defaultContainer.resolveAllWithOptions(opts)
+}
+
+func (c *Container) resolveAllWithOptions(opts Options) (errs.Errors, errs.Errors) {
+	if errs := opts.validate(); errs != nil {
+		return errs, nil
+	}
+
+	c.lock()
+	defer c.mu.Unlock()
+	for p := c.parent; p != nil; p = p.parent {
+		p.lock()
+		defer p.mu.Unlock()
+	}
+
+	errs, warnings := c.resolve(opts)
+	if errs != nil {
+		return errs, warnings
+	}
+
+	_, file, line := caller(2 + opts.CallerSkip)
+	c.resolveSrc = &src{file, line}
+
+	return nil, warnings
+}
+
+func (opts Options) validate() (errs errs.Errors) {
+	for code, severity := range opts.Severities {
+		if severity != SeverityError && !configurableCodes[code] {
+			errs.AddE(&EInvalidSeverity{code, severity})
+		}
+	}
+	return sortErrors(errs)
+}
+
+// split classifies diagnostics, nil is returned instead of empty lists
+func (opts Options) split(diagnostics errs.Errors) (errs errs.Errors, warnings errs.Errors) {
+	for _, d := range diagnostics {
+		switch opts.Severities[ErrorCode(d)] {
+		case SeverityWarning:
+			warnings.AddE(d)
+		case SeverityIgnore:
+		default:
+			errs.AddE(d)
+		}
+	}
+	return errs, warnings
+}
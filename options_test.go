@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveAllWithOptionsDefault(t *testing.T) {
+	Reset()
+	var injected func(x int, y int) int
+
+	Provide(&injected, f)
+
+	errs, warnings := ResolveAllWithOptions(Options{})
+	if _, ok := errs[0].(*EPackageNotUsed); !ok || len(errs) != 1 {
+		t.Fatal(errs)
+	}
+	require.Nil(t, warnings)
+	require.Nil(t, injected)
+}
+
+func TestResolveAllWithOptionsWarnings(t *testing.T) {
+	Reset()
+	var injected1 func(x int, y int) int
+	var injected2 func(x float32) float32
+	mySlice := []string{"str1"}
+
+	Require(&injected1)
+	Provide(&injected1, f)
+	ProvideOverride(&injected2, f2)
+	Provide(&mySlice, []string{})
+	ProvideSliceElement(&mySlice, "str2")
+
+	errs, warnings := ResolveAllWithOptions(Options{Severities: map[string]Severity{
+		"EOverrideWithoutBase":             SeverityWarning,
+		"EImplementationProvidedForNonNil": SeverityIgnore,
+	}})
+	require.Nil(t, errs)
+	if _, ok := warnings[0].(*EOverrideWithoutBase); !ok || len(warnings) != 1 {
+		t.Fatal(warnings)
+	}
+	require.Equal(t, 5, injected1(3, 2))
+	require.Equal(t, []string{"str1", "str2"}, mySlice)
+
+	errs, _ = ResolveAllWithOptions(Options{})
+	if _, ok := errs[0].(*EAlreadyResolved); !ok || len(errs) != 1 {
+		t.Fatal(errs)
+	}
+}
+
+func TestResolveAllWithOptionsKeyValueTypes(t *testing.T) {
+	Reset()
+	myMap := map[string]int{}
+
+	Provide(&myMap, map[string]int{})
+	ProvideKeyValue(&myMap, "key1", "str")
+
+	// key-value types are not checked by default
+	errs := ResolveAll()
+	if _, ok := errs[0].(*EImplementationProvidedForNonNil); !ok || len(errs) != 1 {
+		t.Fatal(errs)
+	}
+
+	errs, warnings := ResolveAllWithOptions(Options{Severities: map[string]Severity{"EImplementationProvidedForNonNil": SeverityWarning}})
+	if _, ok := errs[0].(*EIncompatibleTypesStorageValue); !ok || len(errs) != 1 {
+		t.Fatal(errs)
+	}
+	if _, ok := warnings[0].(*EImplementationProvidedForNonNil); !ok || len(warnings) != 1 {
+		t.Fatal(warnings)
+	}
+}
+
+func TestResolveAllWithOptionsErrorsAndWarnings(t *testing.T) {
+	Reset()
+	var injected1 func(x int, y int) int
+	var injected2 func(x float32) float32
+
+	Require(&injected1)
+	Provide(&injected2, f2)
+
+	errs, warnings := ResolveAllWithOptions(Options{Severities: map[string]Severity{"EPackageNotUsed": SeverityWarning}})
+	if _, ok := errs[0].(*EImplementationNotProvided); !ok || len(errs) != 1 {
+		t.Fatal(errs)
+	}
+	if _, ok := warnings[0].(*EPackageNotUsed); !ok || len(warnings) != 1 {
+		t.Fatal(warnings)
+	}
+}
+
+func TestResolveAllWithOptionsErrorOnInvalidSeverity(t *testing.T) {
+	c := NewContainer()
+	var injected func(x int, y int) int
+
+	c.Require(&injected)
+	errs, warnings := c.ResolveAllWithOptions(Options{Severities: map[string]Severity{
+		"EImplementationNotProvided": SeverityIgnore,
+		"EPackageNotUsed":            SeverityIgnore,
+	}})
+	if e, ok := errs[0].(*EInvalidSeverity); ok && len(errs) == 1 {
+		require.Equal(t, "EImplementationNotProvided", e.code)
+	} else {
+		t.Fatal(errs)
+	}
+	require.Nil(t, warnings)
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"github.com/untillpro/gochips/errs"
+)
+
+// ElementOrder describes position of the element provided by ProvideSliceElementOrdered()
+// Elements provided by ProvideSliceElement() have zero ElementOrder
+type ElementOrder struct {
+	// Name is used by Before and After of other elements
+	Name string
+	// Priority orders elements which are not constrained by Before and After, lower goes first, then in provision order
+	Priority int
+	// Before lists names of elements which must follow the element, unknown names are ignored
+	Before []string
+	// After lists names of elements which must precede the element, unknown names are ignored
+	After []string
+}
+
+// ProvideSliceElementOrdered appends element to the slice at the position described by order
+func (c *Container) ProvideSliceElementOrdered(pointerToSlice interface{}, element interface{}, order ElementOrder) {
+	c.provideSliceElementOrdered(pointerToSlice, element, order)
+}
+
+// ProvideSliceElementOrdered appends element to the slice of the default container at the position described by order
+func ProvideSliceElementOrdered(pointerToSlice interface{}, element interface{}, order ElementOrder) {
+	defaultContainer.provideSliceElementOrdered(pointerToSlice, element, order)
+}
+
+func (c *Container) provideSliceElementOrdered(pointerToSlice interface{}, element interface{}, order ElementOrder) {
+	pkg, file, line := caller(2)
+	srcElement := newSrcElem(file, line, pkg, element)
+	c.addSliceElement(pointerToSlice, srcElement, &order)
+}
+
+// orderSliceElements sorts elements topologically by Before and After, elements which are ready at the same step are sorted by priority and provision order
+// Elements which can't be ordered are appended in provision order and reported by errs
+func (c *Container) orderSliceElements(elems []*srcElem) (sorted []*srcElem, errs errs.Errors) {
+	orders := make([]ElementOrder, len(elems))
+	byName := make(map[string]int)
+	for i, elem := range elems {
+		if order, ok := c.elementOrders[elem]; ok {
+			orders[i] = *order
+		}
+		name := orders[i].Name
+		if len(name) == 0 {
+			continue
+		}
+		if first, ok := byName[name]; ok {
+			errs.AddE(&EMultipleNamedElements{name, []*srcElem{elems[first], elem}})
+			continue
+		}
+		byName[name] = i
+	}
+
+	succs := make([][]int, len(elems))
+	preds := make([][]int, len(elems))
+	addEdge := func(from, to int) {
+		succs[from] = append(succs[from], to)
+		preds[to] = append(preds[to], from)
+	}
+	for i, order := range orders {
+		for _, name := range order.Before {
+			if j, ok := byName[name]; ok {
+				addEdge(i, j)
+			}
+		}
+		for _, name := range order.After {
+			if j, ok := byName[name]; ok {
+				addEdge(j, i)
+			}
+		}
+	}
+
+	inDegree := make([]int, len(elems))
+	for i := range elems {
+		inDegree[i] = len(preds[i])
+	}
+	done := make([]bool, len(elems))
+	for len(sorted) < len(elems) {
+		next := -1
+		for i := range elems {
+			if done[i] || inDegree[i] > 0 {
+				continue
+			}
+			if next < 0 || orders[i].Priority < orders[next].Priority {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		done[next] = true
+		sorted = append(sorted, elems[next])
+		for _, j := range succs[next] {
+			inDegree[j]--
+		}
+	}
+
+	if len(sorted) == len(elems) {
+		return sorted, errs
+	}
+
+	// each remaining element has a remaining predecessor, so walking predecessors leads to a cycle
+	cur := -1
+	for i := range elems {
+		if !done[i] {
+			cur = i
+			break
+		}
+	}
+	visitedAt := make(map[int]int)
+	var path []int
+	for {
+		if at, ok := visitedAt[cur]; ok {
+			path = path[at:]
+			break
+		}
+		visitedAt[cur] = len(path)
+		path = append(path, cur)
+		for _, p := range preds[cur] {
+			if !done[p] {
+				cur = p
+				break
+			}
+		}
+	}
+	var cycle []*srcElem
+	for i := len(path) - 1; i >= 0; i-- {
+		cycle = append(cycle, elems[path[i]])
+	}
+	errs.AddE(&ECyclicElementOrder{cycle})
+
+	for i := range elems {
+		if !done[i] {
+			sorted = append(sorted, elems[i])
+		}
+	}
+	return sorted, errs
+}
+
+// orderedSliceElements returns elements to append in the order of application, errors are reported by validate()
+func (c *Container) orderedSliceElements(elems []*srcElem) []*srcElem {
+	sorted, _ := c.orderSliceElements(elems)
+	return sorted
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSliceElementOrder(t *testing.T) {
+	Reset()
+	var mySlice []string
+
+	ProvideSliceElement(&mySlice, "plain1")
+	ProvideSliceElementOrdered(&mySlice, "auth", ElementOrder{Name: "auth", After: []string{"log"}})
+	ProvideSliceElementOrdered(&mySlice, "last", ElementOrder{Priority: 100})
+	ProvideSliceElementOrdered(&mySlice, "log", ElementOrder{Name: "log"})
+	ProvideSliceElementOrdered(&mySlice, "first", ElementOrder{Priority: -100, Before: []string{"unknown"}})
+	ProvideSliceElementOrdered(&mySlice, []string{"cache1", "cache2"}, ElementOrder{Before: []string{"log"}})
+	ProvideSliceElement(&mySlice, "plain2")
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, []string{"first", "plain1", "cache1", "cache2", "log", "auth", "plain2", "last"}, mySlice)
+}
+
+func TestSliceElementOrderInChild(t *testing.T) {
+	Reset()
+	var mySlice []string
+
+	ProvideSliceElement(&mySlice, "parent")
+	require.Nil(t, ResolveAll())
+
+	child := NewChild()
+	child.ProvideSliceElementOrdered(&mySlice, "child2", ElementOrder{Name: "child2"})
+	child.ProvideSliceElementOrdered(&mySlice, "child1", ElementOrder{Before: []string{"child2"}})
+	require.Nil(t, child.ResolveAll())
+	require.Equal(t, []string{"parent", "child1", "child2"}, mySlice)
+}
+
+func TestSliceElementOrderErrorOnCycle(t *testing.T) {
+	Reset()
+	var mySlice []string
+
+	ProvideSliceElementOrdered(&mySlice, "free", ElementOrder{Name: "free"})
+	_, file, line, _ := runtime.Caller(0)
+	ProvideSliceElementOrdered(&mySlice, "a", ElementOrder{Name: "a", Before: []string{"b"}})
+	ProvideSliceElementOrdered(&mySlice, "b", ElementOrder{Name: "b", Before: []string{"c"}})
+	ProvideSliceElementOrdered(&mySlice, "c", ElementOrder{Name: "c", Before: []string{"a"}, After: []string{"free"}})
+
+	errs := ResolveAll()
+	if e, ok := errs[0].(*ECyclicElementOrder); ok && len(errs) == 1 {
+		require.Len(t, e.elems, 3)
+		lines := map[int]bool{}
+		for _, elem := range e.elems {
+			require.Equal(t, file, elem.file)
+			lines[elem.line] = true
+		}
+		require.Equal(t, map[int]bool{line + 1: true, line + 2: true, line + 3: true}, lines)
+	} else {
+		t.Fatal(errs)
+	}
+}
+
+func TestSliceElementOrderErrorOnMultipleNames(t *testing.T) {
+	Reset()
+	var mySlice []string
+
+	_, file, line, _ := runtime.Caller(0)
+	ProvideSliceElementOrdered(&mySlice, "a", ElementOrder{Name: "a"})
+	ProvideSliceElementOrdered(&mySlice, "b", ElementOrder{Name: "a"})
+
+	errs := ResolveAll()
+	if e, ok := errs[0].(*EMultipleNamedElements); ok && len(errs) == 1 {
+		require.Equal(t, "a", e.name)
+		require.Equal(t, file, e.elems[0].file)
+		require.Equal(t, line+1, e.elems[0].line)
+		require.Equal(t, line+2, e.elems[1].line)
+	} else {
+		t.Fatal(errs)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"github.com/untillpro/gochips/errs"
+)
+
+// ProvideOverride registers implementation of ref type which replaces the regular one
+// E.g. tests can swap one function without re-declaring all packages
+func (c *Container) ProvideOverride(ref interface{}, implementation interface{}) {
+	c.provideOverride(ref, implementation)
+}
+
+// ProvideOverride registers implementation of ref type in the default container which replaces the regular one
+func ProvideOverride(ref interface{}, implementation interface{}) {
+	defaultContainer.provideOverride(ref, implementation)
+}
+
+func (c *Container) provideOverride(ref interface{}, implementation interface{}) {
+	prov := callerSrcPkgElem(3, implementation)
+	prov.kind = provisionOverride
+	c.addProvision(ref, prov)
+}
+
+func findOverride(provs []*srcPkgElem) *srcPkgElem {
+	for _, prov := range provs {
+		if prov.kind == provisionOverride {
+			return prov
+		}
+	}
+	return nil
+}
+
+// hasBaseProvision returns true if the container or any ancestor has a provision which is not an override
+func (c *Container) hasBaseProvision(target interface{}) bool {
+	for cur := c; cur != nil; cur = cur.parent {
+		for _, prov := range cur.provided[target] {
+			if prov.kind != provisionOverride {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *Container) validateOverrides() (errs errs.Errors) {
+	for target, provs := range c.provided {
+		var overrides []*srcPkgElem
+		for _, prov := range provs {
+			if prov.kind == provisionOverride {
+				overrides = append(overrides, prov)
+			}
+		}
+		if len(overrides) > 1 {
+			errs.AddE(&EMultipleOverrides{overrides})
+		} else if len(overrides) == 1 && !c.hasBaseProvision(target) {
+			errs.AddE(&EOverrideWithoutBase{overrides[0]})
+		}
+	}
+	return errs
+}
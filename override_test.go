@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverrideFunc(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	Require(&injectedFunc)
+	Provide(&injectedFunc, f)
+	ProvideOverride(&injectedFunc, f3)
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 6, injectedFunc(3, 2))
+
+	Reset()
+	require.Nil(t, injectedFunc)
+}
+
+func TestOverrideStorage(t *testing.T) {
+	Reset()
+	var myMap map[string]int
+	var mySlice []string
+
+	Provide(&myMap, map[string]int{"base": 1})
+	ProvideOverride(&myMap, map[string]int{"override": 2})
+	ProvideKeyValue(&myMap, "key", 3)
+	Provide(&mySlice, []string{"base"})
+	ProvideOverride(&mySlice, []string{"override"})
+	ProvideSliceElement(&mySlice, "str")
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, map[string]int{"override": 2, "key": 3}, myMap)
+	require.Equal(t, []string{"override", "str"}, mySlice)
+}
+
+func TestOverrideWinsOverSelection(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	Require(&injectedFunc)
+	ProvideNamed(&injectedFunc, "sum", f)
+	ProvideNamed(&injectedFunc, "mul", f3)
+	Select(&injectedFunc, "sum")
+	ProvideOverride(&injectedFunc, func(x int, y int) int { return x - y })
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 1, injectedFunc(3, 2))
+}
+
+func TestOverrideErrorOnNoBase(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	Require(&injectedFunc)
+	_, file, line, _ := runtime.Caller(0)
+	ProvideOverride(&injectedFunc, f3)
+
+	errs := ResolveAll()
+	if e, ok := errs[0].(*EOverrideWithoutBase); ok && len(errs) == 1 {
+		fmt.Println(errs)
+		require.Equal(t, file, e.prov.file)
+		require.Equal(t, line+1, e.prov.line)
+	} else {
+		t.Fatal(errs)
+	}
+	require.Nil(t, injectedFunc)
+}
+
+func TestOverrideErrorOnMultipleOverrides(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+
+	Require(&injectedFunc)
+	Provide(&injectedFunc, f)
+	_, file1, line1, _ := runtime.Caller(0)
+	ProvideOverride(&injectedFunc, f3)
+	_, file2, line2, _ := runtime.Caller(0)
+	ProvideOverride(&injectedFunc, f3)
+
+	errs := ResolveAll()
+	if e, ok := errs[0].(*EMultipleOverrides); ok && len(errs) == 1 {
+		fmt.Println(errs)
+		require.Equal(t, 2, len(e.provs))
+		require.Equal(t, file1, e.provs[0].file)
+		require.Equal(t, line1+1, e.provs[0].line)
+		require.Equal(t, file2, e.provs[1].file)
+		require.Equal(t, line2+1, e.provs[1].line)
+	} else {
+		t.Fatal(errs)
+	}
+	require.Nil(t, injectedFunc)
+}
+
+func TestOverrideInChild(t *testing.T) {
+	t.Parallel()
+	parent := NewContainer()
+	var injectedFunc func(x int, y int) int
+
+	parent.Require(&injectedFunc)
+	parent.Provide(&injectedFunc, f)
+	require.Nil(t, parent.ResolveAll())
+
+	// base provision is taken from the parent
+	child := parent.NewChild()
+	child.ProvideOverride(&injectedFunc, f3)
+	require.Nil(t, child.ResolveAll())
+	require.Equal(t, 6, injectedFunc(3, 2))
+
+	child.Reset()
+	require.Equal(t, 5, injectedFunc(3, 2))
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// ProfilesEnvVar lists active profiles separated by comma, e.g. "test,local", if Options.Profiles is nil
+const ProfilesEnvVar = "GODIF_PROFILES"
+
+// ProvideIn registers implementation of ref type which is considered only if the profile is active
+// Provisions of inactive profiles are ignored by validation and injection as if they were not provided
+func (c *Container) ProvideIn(profile string, ref interface{}, implementation interface{}) {
+	c.provideIn(profile, ref, implementation)
+}
+
+// ProvideIn registers implementation of ref type in the default container which is considered only if the profile is active
+func ProvideIn(profile string, ref interface{}, implementation interface{}) {
+	defaultContainer.provideIn(profile, ref, implementation)
+}
+
+func (c *Container) provideIn(profile string, ref interface{}, implementation interface{}) {
+	prov := callerSrcPkgElem(3, implementation)
+	prov.profile = profile
+	c.lock()
+	defer c.mu.Unlock()
+	if isHashable(ref) {
+		c.profiled[ref] = append(c.profiled[ref], prov)
+	} else {
+		c.unhashableProvs = append(c.unhashableProvs, prov.src)
+	}
+}
+
+// activeProfiles returns profiles given by options or, if there are none, by ProfilesEnvVar environment variable
+func (opts Options) activeProfiles() map[string]bool {
+	profiles := opts.Profiles
+	if profiles == nil {
+		profiles = strings.Split(os.Getenv(ProfilesEnvVar), ",")
+	}
+	res := make(map[string]bool)
+	for _, profile := range profiles {
+		if profile = strings.TrimSpace(profile); len(profile) > 0 {
+			res[profile] = true
+		}
+	}
+	return res
+}
+
+// activateProfiles makes provisions of active profiles and only them visible among other provisions of the container
+func (c *Container) activateProfiles(profiles map[string]bool) {
+	c.profiles = profiles
+	for target, provs := range c.provided {
+		var res []*srcPkgElem
+		for _, prov := range provs {
+			if len(prov.profile) == 0 {
+				res = append(res, prov)
+			}
+		}
+		if res == nil {
+			delete(c.provided, target)
+		} else {
+			c.provided[target] = res
+		}
+	}
+	for target, provs := range c.profiled {
+		for _, prov := range provs {
+			if profiles[prov.profile] {
+				c.provided[target] = append(c.provided[target], prov)
+			}
+		}
+		// keep provision order, e.g. the first provision is reported
+		sort.SliceStable(c.provided[target], func(i, j int) bool {
+			return c.provided[target][i].seq < c.provided[target][j].seq
+		})
+	}
+}
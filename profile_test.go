@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiles(t *testing.T) {
+	var injectedFunc func(x int, y int) int
+	wire := func(c *Container) {
+		c.Require(&injectedFunc)
+		c.ProvideIn("test", &injectedFunc, func(x int, y int) int { return 1 })
+		c.ProvideIn("prod", &injectedFunc, f)
+	}
+
+	// both wirings in one run
+	c := NewContainer()
+	wire(c)
+	errs, _ := c.ResolveAllWithOptions(Options{Profiles: []string{"test"}})
+	require.Nil(t, errs)
+	require.Equal(t, 1, injectedFunc(3, 2))
+	require.Contains(t, c.Report(), `(profile "test")`)
+	c.Reset()
+
+	c = NewContainer()
+	wire(c)
+	errs, _ = c.ResolveAllWithOptions(Options{Profiles: []string{"prod"}})
+	require.Nil(t, errs)
+	require.Equal(t, 5, injectedFunc(3, 2))
+	c.Reset()
+
+	// no active profiles
+	c = NewContainer()
+	wire(c)
+	errs, _ = c.ResolveAllWithOptions(Options{Profiles: []string{}})
+	if e, ok := errs[0].(*EImplementationNotProvided); ok && len(errs) == 1 {
+		require.Equal(t, &injectedFunc, e.req.elem)
+	} else {
+		t.Fatal(errs)
+	}
+
+	// all active profiles are considered
+	c = NewContainer()
+	wire(c)
+	errs, _ = c.ResolveAllWithOptions(Options{Profiles: []string{"test", "prod"}})
+	if e, ok := errs[0].(*EMultipleFuncImplementations); ok && len(errs) == 1 {
+		require.Equal(t, 2, len(e.provs))
+	} else {
+		t.Fatal(errs)
+	}
+}
+
+func TestProfilesFromEnv(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+	t.Setenv(ProfilesEnvVar, "local, prod")
+
+	Require(&injectedFunc)
+	ProvideIn("test", &injectedFunc, func(x int, y int) int { return 1 })
+	ProvideIn("prod", &injectedFunc, f)
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 5, injectedFunc(3, 2))
+
+	// profiles given by options win
+	Reset()
+	Require(&injectedFunc)
+	ProvideIn("test", &injectedFunc, func(x int, y int) int { return 1 })
+	ProvideIn("prod", &injectedFunc, f)
+	errs, _ = ResolveAllWithOptions(Options{Profiles: []string{"test"}})
+	require.Nil(t, errs)
+	require.Equal(t, 1, injectedFunc(3, 2))
+}
+
+func TestInactiveProfileIsNotValidated(t *testing.T) {
+	var injectedFunc func(x int, y int) int
+	c := NewContainer()
+	// the package is not required, but the provision is ignored
+	c.ProvideIn("prod", &injectedFunc, f)
+	errs, _ := c.ResolveAllWithOptions(Options{Profiles: []string{"test"}})
+	require.Nil(t, errs)
+	require.Nil(t, injectedFunc)
+
+	c = NewContainer()
+	c.ProvideIn("prod", &injectedFunc, f)
+	errs, _ = c.ResolveAllWithOptions(Options{Profiles: []string{"prod"}})
+	if _, ok := errs[0].(*EPackageNotUsed); !ok || len(errs) != 1 {
+		t.Fatal(errs)
+	}
+}
+
+func TestProfilesOfRegistry(t *testing.T) {
+	var injectedFunc func(x int, y int) int
+	c := NewContainer()
+	c.Require(&injectedFunc)
+	c.ProvideIn("test", &injectedFunc, func(x int, y int) int { return 1 })
+	c.ProvideIn("prod", &injectedFunc, f)
+	errs, _ := c.ResolveAllWithOptions(Options{Profiles: []string{"prod"}})
+	require.Nil(t, errs)
+	defer c.Reset()
+
+	provs := c.Registry().Provisions
+	require.Equal(t, 2, len(provs))
+	require.Equal(t, "test", provs[0].Profile)
+	require.False(t, provs[0].Injected)
+	require.Equal(t, "prod", provs[1].Profile)
+	require.True(t, provs[1].Injected)
+}
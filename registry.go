@@ -0,0 +1,229 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Location is a place in the source code where something is registered
+type Location struct {
+	File string
+	Line int
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+// Provision kinds reported by Registry()
+const (
+	KindRegular     = "regular"
+	KindOverride    = "override"
+	KindDefault     = "default"
+	KindFallback    = "fallback"
+	KindConstructor = "constructor"
+	KindLazy        = "lazy"
+	KindDecorator   = "decorator"
+	KindStacked     = "stacked"
+)
+
+// RequirementInfo describes a requirement registered by Require(), RequireOptional() and similar functions
+type RequirementInfo struct {
+	Location
+	// Target is the pointer given to Require()
+	Target     interface{}
+	TargetType reflect.Type
+	// Package is the package which requires the target
+	Package  string
+	Optional bool
+	Injected bool
+}
+
+// ProvisionInfo describes an implementation, constructor, lazy factory, decorator or stacked layer of the target
+type ProvisionInfo struct {
+	Location
+	Target     interface{}
+	TargetType reflect.Type
+	// ImplType is the type of the provided value, e.g. type of constructor
+	ImplType reflect.Type
+	Package  string
+	Kind     string
+	// Name is given by ProvideNamed()
+	Name string
+	// Profile is given by ProvideIn()
+	Profile  string
+	Injected bool
+}
+
+// KeyValueInfo describes a value provided by ProvideKeyValue()
+type KeyValueInfo struct {
+	Location
+	Target     interface{}
+	TargetType reflect.Type
+	Key        interface{}
+	ValueType  reflect.Type
+	Package    string
+	Injected   bool
+}
+
+// SliceElementInfo describes an element provided by ProvideSliceElement()
+type SliceElementInfo struct {
+	Location
+	Target     interface{}
+	TargetType reflect.Type
+	// ElementType is the type of the provided value, which is a slice if few elements are provided at once
+	ElementType reflect.Type
+	Package     string
+	Injected    bool
+}
+
+// RegistrySnapshot lists what is registered in the container, each list is sorted by location
+type RegistrySnapshot struct {
+	Requirements  []RequirementInfo
+	Provisions    []ProvisionInfo
+	KeyValues     []KeyValueInfo
+	SliceElements []SliceElementInfo
+}
+
+// Registry returns snapshot of requirements and provisions registered in the container, ancestors are not included
+func (c *Container) Registry() RegistrySnapshot {
+	c.lock()
+	defer c.mu.Unlock()
+	for p := c.parent; p != nil; p = p.parent {
+		p.lock()
+		defer p.mu.Unlock()
+	}
+	return c.registry()
+}
+
+// Registry returns snapshot of requirements and provisions registered in the default container
+func Registry() RegistrySnapshot {
+	return defaultContainer.Registry()
+}
+
+func (c *Container) registry() (res RegistrySnapshot) {
+	for target, req := range c.required {
+		res.Requirements = append(res.Requirements, RequirementInfo{
+			Location:   req.location(),
+			Target:     target,
+			TargetType: reflect.TypeOf(target).Elem(),
+			Package:    req.pkg,
+			Optional:   c.optional[target],
+			Injected:   c.lookupInjected(target) != nil,
+		})
+	}
+	for target, provs := range c.provided {
+		injected := c.injected[target]
+		for _, prov := range provs {
+			res.Provisions = append(res.Provisions, c.provisionInfo(target, prov, prov.kindName(), injected == prov))
+		}
+	}
+	// provisions of inactive profiles
+	for target, provs := range c.profiled {
+		for _, prov := range provs {
+			if !c.profiles[prov.profile] {
+				res.Provisions = append(res.Provisions, c.provisionInfo(target, prov, prov.kindName(), false))
+			}
+		}
+	}
+	for target, decs := range c.decorators {
+		_, injected := c.injected[target]
+		for _, dec := range decs {
+			kind := KindDecorator
+			if dec.stacked {
+				kind = KindStacked
+			}
+			res.Provisions = append(res.Provisions, c.provisionInfo(target, dec.srcPkgElem, kind, injected && c.resolvedProvs[dec.srcPkgElem]))
+		}
+	}
+	for target, kv := range c.keyValues {
+		for _, key := range c.keyOrder[target] {
+			for _, elem := range kv[key] {
+				res.KeyValues = append(res.KeyValues, KeyValueInfo{
+					Location:   elem.location(),
+					Target:     target,
+					TargetType: reflect.TypeOf(target).Elem(),
+					Key:        key,
+					ValueType:  reflect.TypeOf(elem.elem),
+					Package:    elem.pkg,
+					Injected:   c.resolvedElems[elem],
+				})
+			}
+		}
+	}
+	for target, elems := range c.sliceElements {
+		for _, elem := range elems {
+			res.SliceElements = append(res.SliceElements, SliceElementInfo{
+				Location:    elem.location(),
+				Target:      target,
+				TargetType:  reflect.TypeOf(target).Elem(),
+				ElementType: reflect.TypeOf(elem.elem),
+				Package:     elem.pkg,
+				Injected:    c.resolvedElems[elem],
+			})
+		}
+	}
+
+	sort.SliceStable(res.Requirements, func(i, j int) bool {
+		return res.Requirements[i].Location.less(res.Requirements[j].Location)
+	})
+	sort.SliceStable(res.Provisions, func(i, j int) bool {
+		return res.Provisions[i].Location.less(res.Provisions[j].Location)
+	})
+	sort.SliceStable(res.KeyValues, func(i, j int) bool {
+		return res.KeyValues[i].Location.less(res.KeyValues[j].Location)
+	})
+	sort.SliceStable(res.SliceElements, func(i, j int) bool {
+		return res.SliceElements[i].Location.less(res.SliceElements[j].Location)
+	})
+	return res
+}
+
+func (c *Container) provisionInfo(target interface{}, prov *srcPkgElem, kind string, injected bool) ProvisionInfo {
+	return ProvisionInfo{
+		Location:   prov.location(),
+		Target:     target,
+		TargetType: reflect.TypeOf(target).Elem(),
+		ImplType:   reflect.TypeOf(prov.elem),
+		Package:    prov.pkg,
+		Kind:       kind,
+		Name:       prov.name,
+		Profile:    prov.profile,
+		Injected:   injected,
+	}
+}
+
+func (s *src) location() Location {
+	return Location{s.file, s.line}
+}
+
+func (l Location) less(other Location) bool {
+	if l.File != other.File {
+		return l.File < other.File
+	}
+	return l.Line < other.Line
+}
+
+func (p *srcPkgElem) kindName() string {
+	switch {
+	case p.constructor:
+		return KindConstructor
+	case p.lazy:
+		return KindLazy
+	case p.kind == provisionDefault:
+		return KindDefault
+	case p.kind == provisionFallback:
+		return KindFallback
+	case p.kind == provisionOverride:
+		return KindOverride
+	}
+	return KindRegular
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const godifPkg = "github.com/untillpro/godif"
+
+func TestRegistry(t *testing.T) {
+	Reset()
+	var injectedFunc func(x int, y int) int
+	var optionalFunc func(x float32) float32
+	var myMap map[string]int
+	var mySlice []string
+
+	_, file, line, _ := runtime.Caller(0)
+	Require(&injectedFunc)
+	Provide(&injectedFunc, f)
+	ProvideDefault(&injectedFunc, f3)
+	ProvideDecorator(&injectedFunc, func(next func(x int, y int) int) func(x int, y int) int { return next })
+	RequireOptional(&optionalFunc)
+	Provide(&myMap, map[string]int{})
+	ProvideKeyValue(&myMap, "key1", 1)
+	ProvideSliceElement(&mySlice, []string{"str1", "str2"})
+
+	reg := Registry()
+	require.Len(t, reg.Requirements, 2)
+	require.Len(t, reg.Provisions, 4)
+	require.Len(t, reg.KeyValues, 1)
+	require.Len(t, reg.SliceElements, 1)
+	for _, prov := range reg.Provisions {
+		require.False(t, prov.Injected)
+	}
+
+	require.Nil(t, ResolveAll())
+	reg = Registry()
+
+	require.Equal(t, RequirementInfo{Location{file, line + 1}, &injectedFunc, reflect.TypeOf(injectedFunc), godifPkg, false, true}, reg.Requirements[0])
+	require.Equal(t, RequirementInfo{Location{file, line + 5}, &optionalFunc, reflect.TypeOf(optionalFunc), godifPkg, true, false}, reg.Requirements[1])
+
+	prov := reg.Provisions[0]
+	require.Equal(t, Location{file, line + 2}, prov.Location)
+	require.True(t, prov.Target == &injectedFunc)
+	require.Equal(t, reflect.TypeOf(injectedFunc), prov.TargetType)
+	require.Equal(t, reflect.TypeOf(f), prov.ImplType)
+	require.Equal(t, godifPkg, prov.Package)
+	require.Equal(t, KindRegular, prov.Kind)
+	require.True(t, prov.Injected)
+
+	require.Equal(t, KindDefault, reg.Provisions[1].Kind)
+	require.False(t, reg.Provisions[1].Injected)
+	require.Equal(t, KindDecorator, reg.Provisions[2].Kind)
+	require.True(t, reg.Provisions[2].Injected)
+	require.Equal(t, reflect.TypeOf(myMap), reg.Provisions[3].TargetType)
+
+	require.Equal(t, KeyValueInfo{Location{file, line + 7}, &myMap, reflect.TypeOf(myMap), "key1", reflect.TypeOf(1), godifPkg, true}, reg.KeyValues[0])
+	require.Equal(t, SliceElementInfo{Location{file, line + 8}, &mySlice, reflect.TypeOf(mySlice), reflect.TypeOf(mySlice), godifPkg, true}, reg.SliceElements[0])
+	require.Equal(t, fmt.Sprintf("%s:%d", file, line+8), reg.SliceElements[0].Location.String())
+}
+
+type registryProvider struct{}
+
+func (p *registryProvider) provide(target *int) {
+	Provide(target, 1)
+}
+
+func provideGeneric[T any](target *T, v T) {
+	Provide(target, v)
+}
+
+func TestRegistryPackageOfCaller(t *testing.T) {
+	Reset()
+	var fromMethod, fromGeneric, fromClosure int
+
+	(&registryProvider{}).provide(&fromMethod)
+	provideGeneric(&fromGeneric, 2)
+	func() {
+		Provide(&fromClosure, 3)
+	}()
+
+	reg := Registry()
+	require.Len(t, reg.Provisions, 3)
+	for _, prov := range reg.Provisions {
+		require.Equal(t, godifPkg, prov.Package)
+	}
+	require.Equal(t, "gopkg.in/yaml.v2", funcPackage("gopkg.in/yaml%2ev2.Unmarshal"))
+	require.Equal(t, godifPkg, funcPackage(godifPkg+".F[...]"))
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Report describes which provisions were injected by the last ResolveAll(), one line per target
+// E.g. `func(int, int) int by github.com/untillpro/godif at /src/godif/godif_test.go:42 (default)`
+func (c *Container) Report() string {
+	c.lock()
+	defer c.mu.Unlock()
+	var lines []string
+	for target, prov := range c.injected {
+		lines = append(lines, fmt.Sprintf("%s by %s at %s:%d%s", reflect.TypeOf(target).Elem(), prov.pkg, prov.file, prov.line, prov.kindSuffix()))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// Report describes which provisions were injected by the last ResolveAll() of the default container
+func Report() string {
+	return defaultContainer.Report()
+}
+
+func (p *srcPkgElem) kindSuffix() string {
+	switch {
+	case p.constructor:
+		return " (constructor)"
+	case p.lazy:
+		return " (lazy)"
+	case p.kind == provisionDefault:
+		return " (default)"
+	case p.kind == provisionFallback:
+		return " (fallback)"
+	case p.kind == provisionOverride:
+		return " (override)"
+	case len(p.name) > 0:
+		return fmt.Sprintf(" (named %q)", p.name)
+	case len(p.profile) > 0:
+		return fmt.Sprintf(" (profile %q)", p.profile)
+	}
+	return ""
+}
@@ -20,6 +20,7 @@ import (
 )
 
 // Services should be provided by godif.ProvideSliceElement(&services.Services, ...)
+// Use godif.ProvideSliceElementOrdered() if service must be started before or after other ones
 var Services []IService
 
 // SetVerbose changes logging defaults (by default verbose is true)
@@ -53,7 +54,7 @@ func Run() error {
 }
 
 // StartServices starts all services registered in Services
-// Calls Services' Start methods in order of provision
+// Calls Services' Start methods in order of provision, godif.ElementOrder of elements is respected
 // If any error/panic occurs it immediately returns
 func StartServices(ctx context.Context) (newCtx context.Context, err error) {
 	newCtx, started, err = Start(ctx, Services, verboseOutput)
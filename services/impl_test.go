@@ -187,6 +187,22 @@ func TestFailedStart(t *testing.T) {
 	assert.Equal(t, 0, s2.State)
 }
 
+func TestOrderedServices(t *testing.T) {
+
+	s1 := &MyService{Name: "Service1"}
+	s2 := &MyService{Name: "Service2"}
+	s3 := &MyService{Name: "Service3"}
+	godif.ProvideSliceElementOrdered(&Services, s1, godif.ElementOrder{After: []string{"db"}})
+	godif.ProvideSliceElement(&Services, s2)
+	godif.ProvideSliceElementOrdered(&Services, s3, godif.ElementOrder{Name: "db", Priority: -1})
+	Declare()
+
+	errs := godif.ResolveAll()
+	defer godif.Reset()
+	require.Nil(t, errs)
+	require.Equal(t, []IService{s3, s1, s2}, Services)
+}
+
 func TestContextStartStopOrder(t *testing.T) {
 
 	ctxKey := ctxKeyType("root")
@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ProvideStacked registers layer of func target, e.g. func(prev F) F, which gets the implementation below it
+// Layers are stacked by ResolveAll() in provision order on top of the injected implementation, decorators wrap the whole stack
+func (c *Container) ProvideStacked(ref interface{}, layer interface{}) {
+	c.provideStacked(ref, layer)
+}
+
+// ProvideStacked registers layer of func target in the default container
+func ProvideStacked(ref interface{}, layer interface{}) {
+	defaultContainer.provideStacked(ref, layer)
+}
+
+// DumpStack describes layers of the target injected by the last ResolveAll(), top (called first) to bottom, one line per layer
+// E.g. `  layer by github.com/untillpro/godif at /src/godif/stack_test.go:42` follows the line with the target type
+func (c *Container) DumpStack(target interface{}) string {
+	c.lock()
+	defer c.mu.Unlock()
+	for p := c.parent; p != nil; p = p.parent {
+		p.lock()
+		defer p.mu.Unlock()
+	}
+	return c.dumpStack(target)
+}
+
+// DumpStack describes layers of the target injected by the last ResolveAll() of the default container
+func DumpStack(target interface{}) string {
+	return defaultContainer.DumpStack(target)
+}
+
+func (c *Container) provideStacked(ref interface{}, layer interface{}) {
+	prov := callerSrcPkgElem(3, layer)
+	c.lock()
+	defer c.mu.Unlock()
+	if isHashable(ref) {
+		c.decorators[ref] = append(c.decorators[ref], &decorator{srcPkgElem: prov, stacked: true})
+	} else {
+		c.unhashableProvs = append(c.unhashableProvs, prov.src)
+	}
+}
+
+// stackOf returns injected provision of the target and layers and decorators in the order of application, like ResolveAll() applies them
+func (c *Container) stackOf(target interface{}) (*srcPkgElem, []*decorator) {
+	var below []*Container
+	for cur := c; cur != nil; cur = cur.parent {
+		prov, ok := cur.injected[target]
+		if !ok {
+			below = append(below, cur)
+			continue
+		}
+		decs := cur.lookupDecorators(target)
+		// containers below wrap the inherited implementation with own decorators
+		for i := len(below) - 1; i >= 0; i-- {
+			decs = append(decs, sortDecorators(append([]*decorator{}, below[i].decorators[target]...))...)
+		}
+		return prov, decs
+	}
+	return nil, nil
+}
+
+func (c *Container) dumpStack(target interface{}) string {
+	lines := []string{reflect.TypeOf(target).Elem().String()}
+	prov, decs := c.stackOf(target)
+	if prov == nil {
+		return strings.Join(append(lines, "  implementation is not injected"), "\n")
+	}
+	for i := len(decs) - 1; i >= 0; i-- {
+		dec := decs[i]
+		kind := "layer"
+		if !dec.stacked {
+			kind = fmt.Sprintf("decorator (priority %d)", dec.priority)
+		}
+		lines = append(lines, fmt.Sprintf("  %s by %s at %s:%d", kind, dec.pkg, dec.file, dec.line))
+	}
+	lines = append(lines, fmt.Sprintf("  implementation by %s at %s:%d%s", prov.pkg, prov.file, prov.line, prov.kindSuffix()))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func appendLayer(suffix string) func(prev func(s string) string) func(s string) string {
+	return func(prev func(s string) string) func(s string) string {
+		return func(s string) string {
+			return prev(s) + suffix
+		}
+	}
+}
+
+func TestStackedLayers(t *testing.T) {
+	Reset()
+	var injectedFunc func(s string) string
+
+	Require(&injectedFunc)
+	ProvideStacked(&injectedFunc, appendLayer("-first"))
+	ProvideDecorator(&injectedFunc, func(next func(s string) string) func(s string) string {
+		return func(s string) string { return "[" + next(s) + "]" }
+	})
+	ProvideStacked(&injectedFunc, appendLayer("-second"))
+	Provide(&injectedFunc, func(s string) string { return s })
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	// each layer calls the previous one, decorators wrap the whole stack
+	require.Equal(t, "[base-first-second]", injectedFunc("base"))
+}
+
+func TestDumpStack(t *testing.T) {
+	Reset()
+	var injectedFunc func(s string) string
+
+	Require(&injectedFunc)
+	ProvideDefault(&injectedFunc, func(s string) string { return s })
+	_, file, baseLine, _ := runtime.Caller(0)
+	ProvideStacked(&injectedFunc, appendLayer("-first"))
+	ProvideDecoratorPriority(&injectedFunc, appendLayer("-decorated"), 5)
+	ProvideStacked(&injectedFunc, appendLayer("-second"))
+
+	require.Equal(t, "func(string) string\n  implementation is not injected", DumpStack(&injectedFunc))
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, "base-first-second-decorated", injectedFunc("base"))
+	require.Equal(t, strings.Join([]string{
+		"func(string) string",
+		fmt.Sprintf("  decorator (priority 5) by %s at %s:%d", godifPkg, file, baseLine+2),
+		fmt.Sprintf("  layer by %s at %s:%d", godifPkg, file, baseLine+3),
+		fmt.Sprintf("  layer by %s at %s:%d", godifPkg, file, baseLine+1),
+		fmt.Sprintf("  implementation by %s at %s:%d (default)", godifPkg, file, baseLine-1),
+	}, "\n"), DumpStack(&injectedFunc))
+}
+
+func TestStackedLayersOfChild(t *testing.T) {
+	var injectedFunc func(s string) string
+	parent := NewContainer()
+	parent.Require(&injectedFunc)
+	parent.Provide(&injectedFunc, func(s string) string { return s })
+	parent.ProvideStacked(&injectedFunc, appendLayer("-parent"))
+	errs := parent.ResolveAll()
+	require.Nil(t, errs)
+
+	child := parent.NewChild()
+	_, file, line, _ := runtime.Caller(0)
+	child.ProvideStacked(&injectedFunc, appendLayer("-child"))
+	errs = child.ResolveAll()
+	require.Nil(t, errs)
+	defer child.Reset()
+
+	// layers of the child are stacked on top of the inherited implementation
+	require.Equal(t, "base-parent-child", injectedFunc("base"))
+	lines := strings.Split(child.DumpStack(&injectedFunc), "\n")
+	require.Equal(t, 4, len(lines))
+	require.Equal(t, fmt.Sprintf("  layer by %s at %s:%d", godifPkg, file, line+1), lines[1])
+}
+
+func TestStackedLayerErrorOnIncompatibleTypes(t *testing.T) {
+	Reset()
+	var injectedFunc func(s string) string
+
+	Require(&injectedFunc)
+	Provide(&injectedFunc, func(s string) string { return s })
+	ProvideStacked(&injectedFunc, func(prev func(s string) string) func(s int) string { return nil })
+	_, _, line, _ := runtime.Caller(0)
+
+	errs := ResolveAll()
+	if e, ok := errs[0].(*EIncompatibleTypesStackedLayer); ok && len(errs) == 1 {
+		require.Equal(t, line-1, e.prov.line)
+		require.Contains(t, e.Error(), "stacked layer")
+	} else {
+		t.Fatal(errs)
+	}
+}
+
+func TestRegistryStackedKind(t *testing.T) {
+	c := NewContainer()
+	var injectedFunc func(s string) string
+	c.ProvideStacked(&injectedFunc, appendLayer("-layer"))
+
+	provs := c.Registry().Provisions
+	require.Equal(t, 1, len(provs))
+	require.Equal(t, KindStacked, provs[0].Kind)
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type IStore interface {
+	Get(key string) string
+	Put(key string, value string)
+}
+
+type memStore struct {
+	data map[string]string
+}
+
+func (s *memStore) Get(key string) string {
+	return s.data[key]
+}
+
+func (s *memStore) Put(key string, value string) {
+	s.data[key] = value
+}
+
+type readOnlyStore struct{}
+
+func (s *readOnlyStore) Get(key string) int {
+	return 0
+}
+
+type config struct {
+	Name string
+}
+
+type anotherConfig struct {
+	Name string
+}
+
+func TestInterfaceTarget(t *testing.T) {
+	Reset()
+	var store IStore
+
+	Require(&store)
+	Provide(&store, &memStore{data: map[string]string{}})
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	store.Put("key", "value")
+	require.Equal(t, "value", store.Get("key"))
+
+	Reset()
+	require.Nil(t, store)
+}
+
+func TestPointerTarget(t *testing.T) {
+	Reset()
+	var cfg *config
+
+	Require(&cfg)
+	Provide(&cfg, &config{Name: "name"})
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, "name", cfg.Name)
+
+	Reset()
+	require.Nil(t, cfg)
+}
+
+func TestInterfaceTargetErrorOnNotImplemented(t *testing.T) {
+	Reset()
+	var store IStore
+
+	Require(&store)
+	_, file, line, _ := runtime.Caller(0)
+	Provide(&store, &readOnlyStore{})
+
+	errs := ResolveAll()
+	if e, ok := errs[0].(*EInterfaceNotImplemented); ok && len(errs) == 1 {
+		fmt.Println(errs)
+		require.Equal(t, file, e.prov.file)
+		require.Equal(t, line+1, e.prov.line)
+		require.Equal(t, 2, len(e.missing))
+		require.True(t, strings.HasPrefix(e.missing[0], "Get (has func(string) int"), e.missing[0])
+		require.Equal(t, "Put", e.missing[1])
+	} else {
+		t.Fatal(errs)
+	}
+	require.Nil(t, store)
+}
+
+func TestPointerTargetErrorOnIncompatibleTypes(t *testing.T) {
+	Reset()
+	var cfg *config
+
+	Require(&cfg)
+	Provide(&cfg, &anotherConfig{})
+
+	errs := ResolveAll()
+	if _, ok := errs[0].(*EIncompatibleTypesPointer); ok && len(errs) == 1 {
+		fmt.Println(errs)
+	} else {
+		t.Fatal(errs)
+	}
+	require.Nil(t, cfg)
+}
+
+func TestInterfaceTargetErrorOnMultipleImplementations(t *testing.T) {
+	Reset()
+	var store IStore
+
+	Require(&store)
+	Provide(&store, &memStore{})
+	Provide(&store, &memStore{})
+
+	errs := ResolveAll()
+	if _, ok := errs[0].(*EMultipleFuncImplementations); ok && len(errs) == 1 {
+		fmt.Println(errs)
+	} else {
+		t.Fatal(errs)
+	}
+}
+
+func TestInterfaceAndPointerTargetsPackageNotUsed(t *testing.T) {
+	Reset()
+	var store IStore
+	var cfg *config
+
+	Provide(&store, &memStore{})
+	Provide(&cfg, &config{})
+
+	errs := ResolveAll()
+	if e, ok := errs[0].(*EPackageNotUsed); ok && len(errs) == 1 {
+		fmt.Println(errs)
+		require.Equal(t, "github.com/untillpro/godif", e.pkgName)
+	} else {
+		t.Fatal(errs)
+	}
+
+	// Package is used if something required
+	Reset()
+	Require(&cfg)
+	Provide(&store, &memStore{})
+	Provide(&cfg, &config{})
+	errs = ResolveAll()
+	require.Nil(t, errs)
+	require.Nil(t, store)
+	require.NotNil(t, cfg)
+}
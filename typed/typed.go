@@ -0,0 +1,38 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+// Package typed provides type-safe counterparts of godif functions, so mismatches fail at compile time
+// Functions work with the godif default container, source locations point to callers of typed functions
+package typed
+
+import "github.com/untillpro/godif"
+
+// Provide registers implementation of ref type
+// Specify T explicitly if impl type differs from the target one, e.g. typed.Provide[IStore](&store, &memStore{})
+func Provide[T any](ref *T, impl T) {
+	godif.Provide(ref, impl)
+}
+
+// Require registers dep
+func Require[T any](toInject *T) {
+	godif.Require(toInject)
+}
+
+// ProvideKeyValue provides value for the key of map extension point
+func ProvideKeyValue[K comparable, V any](pointerToMap *map[K]V, key K, value V) {
+	godif.ProvideKeyValue(pointerToMap, key, value)
+}
+
+// ProvideKeySlice appends values to the slice of the key of map extension point
+func ProvideKeySlice[K comparable, V any](pointerToMap *map[K][]V, key K, values ...V) {
+	godif.ProvideKeyValue(pointerToMap, key, values)
+}
+
+// ProvideSliceElement appends elements to slice extension point
+func ProvideSliceElement[E any](pointerToSlice *[]E, elements ...E) {
+	godif.ProvideSliceElement(pointerToSlice, elements)
+}
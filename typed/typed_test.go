@@ -0,0 +1,83 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package typed_test
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/untillpro/godif"
+	"github.com/untillpro/godif/typed"
+)
+
+type iGreeter interface {
+	Greet(name string) string
+}
+
+type greeter struct{}
+
+func (g *greeter) Greet(name string) string {
+	return "Hello, " + name
+}
+
+func sum(x int, y int) int {
+	return x + y
+}
+
+func TestTypedBasic(t *testing.T) {
+	godif.Reset()
+	require := require.New(t)
+	var injectedFunc func(x int, y int) int
+	var g iGreeter
+	var myMap map[string]int
+	var myKeySlice map[string][]int
+	var mySlice []string
+
+	typed.Require(&injectedFunc)
+	typed.Provide(&injectedFunc, sum)
+	typed.Require(&g)
+	typed.Provide[iGreeter](&g, &greeter{})
+	godif.Provide(&myMap, map[string]int{})
+	typed.ProvideKeyValue(&myMap, "key1", 1)
+	godif.Provide(&myKeySlice, map[string][]int{})
+	typed.ProvideKeySlice(&myKeySlice, "key1", 1, 2)
+	typed.ProvideKeySlice(&myKeySlice, "key1", 3)
+	typed.ProvideSliceElement(&mySlice, "str1", "str2")
+	typed.ProvideSliceElement(&mySlice, "str3")
+
+	errs := godif.ResolveAll()
+	require.Nil(errs)
+	require.Equal(5, injectedFunc(3, 2))
+	require.Equal("Hello, world", g.Greet("world"))
+	require.Equal(map[string]int{"key1": 1}, myMap)
+	require.Equal(map[string][]int{"key1": {1, 2, 3}}, myKeySlice)
+	require.Equal([]string{"str1", "str2", "str3"}, mySlice)
+
+	godif.Reset()
+	require.Nil(injectedFunc)
+	require.Nil(g)
+}
+
+func TestTypedSourceLocation(t *testing.T) {
+	godif.Reset()
+	var injectedFunc func(x int, y int) int
+	var myMap map[string]int
+
+	_, file, line, _ := runtime.Caller(0)
+	typed.Require(&injectedFunc)
+	typed.ProvideKeyValue(&myMap, "key1", 1)
+
+	errs := godif.ResolveAll()
+	require.Len(t, errs, 2, errs)
+	msg := errs.Error()
+	require.True(t, strings.Contains(msg, file+":"+strconv.Itoa(line+1)), msg)
+	require.True(t, strings.Contains(msg, file+":"+strconv.Itoa(line+2)), msg)
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Type-keyed API: implementations are registered and got by type, no package variable is needed
+// Each container has own hidden target of the type, so provisions of a child do not affect its parent
+// Requirement of a child uses the target of the nearest ancestor which has one
+
+// ProvideType registers implementation of T in the default container
+// Implementation is injected even if T is not required by RequireType()
+func ProvideType[T any](impl T) {
+	defaultContainer.provideType(typeOf[T](), impl)
+}
+
+// RequireType registers T as a dep of the default container, so ResolveAll() fails if T is not provided
+func RequireType[T any]() {
+	defaultContainer.requireType(typeOf[T]())
+}
+
+// Get returns implementation of T injected by the default container, zero value if T is not injected
+func Get[T any]() T {
+	return GetIn[T](defaultContainer)
+}
+
+// MustGet returns implementation of T injected by the default container, panics if T is not injected
+func MustGet[T any]() T {
+	return MustGetIn[T](defaultContainer)
+}
+
+// ProvideTypeIn registers implementation of T in the given container
+func ProvideTypeIn[T any](c *Container, impl T) {
+	c.provideType(typeOf[T](), impl)
+}
+
+// RequireTypeIn registers T as a dep of the given container
+func RequireTypeIn[T any](c *Container) {
+	c.requireType(typeOf[T]())
+}
+
+// GetIn returns implementation of T injected by the given container or its ancestors, zero value if T is not injected
+func GetIn[T any](c *Container) T {
+	v, _ := c.getType(typeOf[T]())
+	res, _ := v.(T)
+	return res
+}
+
+// MustGetIn returns implementation of T injected by the given container or its ancestors, panics if T is not injected
+func MustGetIn[T any](c *Container) T {
+	v, ok := c.getType(typeOf[T]())
+	if !ok {
+		panic(fmt.Sprintf("Implementation of %s is not injected. Use ProvideType() and ResolveAll()", typeOf[T]()))
+	}
+	res, _ := v.(T)
+	return res
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (c *Container) provideType(t reflect.Type, impl interface{}) {
+	prov := callerSrcPkgElem(3, impl)
+	target := c.typeTarget(t, true)
+	c.addProvision(target, prov)
+	// implicit optional requirement, so the implementation is injected and validated as required one
+	c.addRequirement(newSrcElem(prov.file, prov.line, prov.pkg, target), true)
+}
+
+func (c *Container) requireType(t reflect.Type) {
+	pkg, file, line := caller(2)
+	c.addRequirement(newSrcElem(file, line, pkg, c.typeTarget(t, false)), false)
+}
+
+// typeTarget returns pointer to the hidden target of the type
+// Own target of the container is used if own is true, otherwise the target of the nearest container which has one, own target is created if there is none
+func (c *Container) typeTarget(t reflect.Type, own bool) interface{} {
+	c.lock()
+	defer c.mu.Unlock()
+	for cur := c; cur != nil; cur = cur.parent {
+		if cur != c {
+			cur.lock()
+			defer cur.mu.Unlock()
+		}
+		if target, ok := cur.typeTargets[t]; ok {
+			return target
+		}
+		if own {
+			break
+		}
+	}
+	target := reflect.New(t).Interface()
+	c.typeTargets[t] = target
+	return target
+}
+
+// getType returns implementation injected into own target of the container or into targets of its ancestors, nearest one wins
+func (c *Container) getType(t reflect.Type) (interface{}, bool) {
+	c.lock()
+	defer c.mu.Unlock()
+	for p := c.parent; p != nil; p = p.parent {
+		p.lock()
+		defer p.mu.Unlock()
+	}
+	var targets []interface{}
+	for cur := c; cur != nil; cur = cur.parent {
+		if target, ok := cur.typeTargets[t]; ok {
+			targets = append(targets, target)
+		}
+	}
+	for cur := c; cur != nil; cur = cur.parent {
+		for _, target := range targets {
+			if _, ok := cur.injected[target]; ok {
+				return reflect.ValueOf(target).Elem().Interface(), true
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright (c) 2018-present unTill Pro, Ltd. and Contributors
+ *
+ * This source code is licensed under the MIT license found in the
+ * LICENSE file in the root directory of this source tree.
+ */
+
+package godif
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type typedService interface {
+	Name() string
+}
+
+type typedServiceImpl struct {
+	name string
+}
+
+func (s *typedServiceImpl) Name() string {
+	return s.name
+}
+
+func TestTypeBasic(t *testing.T) {
+	Reset()
+	ProvideType[IStore](&memStore{data: map[string]string{"key": "value"}})
+	ProvideType(&config{Name: "name"})
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, "value", Get[IStore]().Get("key"))
+	require.Equal(t, "name", MustGet[*config]().Name)
+
+	Reset()
+	require.Nil(t, Get[IStore]())
+	require.Panics(t, func() { MustGet[IStore]() })
+}
+
+type typedConfig struct {
+	port int
+}
+
+func TestTypeNotNillable(t *testing.T) {
+	c := NewContainer()
+	ProvideTypeIn(c, 5)
+	ProvideTypeIn(c, typedConfig{8080})
+	RequireTypeIn[typedConfig](c)
+
+	errs := c.ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, 5, MustGetIn[int](c))
+	require.Equal(t, typedConfig{8080}, MustGetIn[typedConfig](c))
+
+	child := c.NewChild()
+	ProvideTypeIn(child, 6)
+	require.Nil(t, child.ResolveAll())
+	require.Equal(t, 6, MustGetIn[int](child))
+	require.Equal(t, 5, MustGetIn[int](c))
+	child.Reset()
+	require.Equal(t, 5, MustGetIn[int](c))
+}
+
+func TestTypeErrorOnNotProvided(t *testing.T) {
+	Reset()
+	_, file, line, _ := runtime.Caller(0)
+	RequireType[IStore]()
+
+	errs := ResolveAll()
+	if e, ok := errs[0].(*EImplementationNotProvided); ok && len(errs) == 1 {
+		fmt.Println(errs)
+		require.Equal(t, file, e.req.file)
+		require.Equal(t, line+1, e.req.line)
+	} else {
+		t.Fatal(errs)
+	}
+}
+
+func TestTypeErrorOnMultipleImplementations(t *testing.T) {
+	Reset()
+	_, reqFile, reqLine, _ := runtime.Caller(0)
+	RequireType[typedService]()
+	ProvideType[typedService](&typedServiceImpl{"first"})
+	ProvideType[typedService](&typedServiceImpl{"second"})
+
+	errs := ResolveAll()
+	if e, ok := errs[0].(*EMultipleFuncImplementations); ok && len(errs) == 1 {
+		fmt.Println(errs)
+		require.Equal(t, reqFile, e.req.file)
+		require.Equal(t, reqLine+1, e.req.line)
+		require.Equal(t, 2, len(e.provs))
+	} else {
+		t.Fatal(errs)
+	}
+	require.Nil(t, Get[typedService]())
+}
+
+func TestTypeErrorOnIncompatibleTypes(t *testing.T) {
+	Reset()
+	var service typedService = &typedServiceImpl{}
+
+	RequireType[IStore]()
+	// impl is checked by compiler, so the only way to provide incompatible implementation is a constructor
+	ProvideConstructor(defaultContainer.typeTarget(typeOf[IStore](), true), func() typedService { return service })
+
+	errs := ResolveAll()
+	if _, ok := errs[0].(*EInterfaceNotImplemented); ok && len(errs) == 1 {
+		fmt.Println(errs)
+	} else {
+		t.Fatal(errs)
+	}
+}
+
+func TestTypeUsedByConstructor(t *testing.T) {
+	Reset()
+	ProvideType(&config{Name: "name"})
+	var service *ctorService
+	Require(&service)
+	ProvideConstructor(&service, func(cfg *config) *ctorService { return &ctorService{cfg: cfg} })
+
+	errs := ResolveAll()
+	require.Nil(t, errs)
+	require.Equal(t, "name", service.cfg.Name)
+}
+
+func TestTypeInChild(t *testing.T) {
+	t.Parallel()
+	parent := NewContainer()
+	ProvideTypeIn[typedService](parent, &typedServiceImpl{"parent"})
+	require.Nil(t, parent.ResolveAll())
+
+	child := parent.NewChild()
+	ProvideTypeIn[typedService](child, &typedServiceImpl{"child"})
+	require.Nil(t, child.ResolveAll())
+	require.Equal(t, "child", MustGetIn[typedService](child).Name())
+	// parent is not affected
+	require.Equal(t, "parent", MustGetIn[typedService](parent).Name())
+
+	child.Reset()
+	require.Equal(t, "parent", MustGetIn[typedService](parent).Name())
+
+	// requirement of a child is satisfied by the parent
+	child2 := parent.NewChild()
+	RequireTypeIn[typedService](child2)
+	require.Nil(t, child2.ResolveAll())
+	require.Equal(t, "parent", MustGetIn[typedService](child2).Name())
+	child2.Reset()
+
+	// containers have own hidden targets
+	other := NewContainer()
+	RequireTypeIn[typedService](other)
+	require.NotNil(t, other.ResolveAll())
+	require.Nil(t, GetIn[typedService](other))
+}